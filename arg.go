@@ -0,0 +1,114 @@
+package cli
+
+import "fmt"
+
+// Arg represents a positional argument.
+type Arg struct {
+	Name        string // the argument name, ie. "SRC"
+	Description string // the argument description
+
+	Required bool // true if the argument is required
+	Variadic bool // true if the argument accepts any number of values
+
+	Type  ValueType // the type the values are converted to when parsed
+	Value Value     // the custom value the values are parsed by (nil for none)
+
+	Completion Completion // how the values are completed by the shell
+}
+
+// NewArg constructs a new positional argument.
+func NewArg(name string, desc string, required bool) *Arg {
+	return &Arg{
+		Name:        name,
+		Description: desc,
+		Required:    required,
+		Variadic:    false,
+	}
+}
+
+// NewVariadicArg constructs a new positional argument accepting any number of values.
+// Required variadic arguments need at least one value.
+func NewVariadicArg(name string, desc string, required bool) *Arg {
+	return &Arg{
+		Name:        name,
+		Description: desc,
+		Required:    required,
+		Variadic:    true,
+	}
+}
+
+// String returns a string representation of this argument.
+func (a Arg) String() string {
+	return fmt.Sprintf(`cli.Arg{Name="%v", Required=%v, Variadic=%v}`, a.Name, a.Required, a.Variadic)
+}
+
+// usage returns the argument as shown in a usage statement, ie. "SRC...", "[DEST]".
+func (a *Arg) usage() string {
+	s := a.Name
+	if a.Variadic {
+		s += "..."
+	}
+	if !a.Required {
+		s = "[" + s + "]"
+	}
+	return s
+}
+
+// assignArgs assigns the tokens to the positional arguments.
+// Arguments before a variadic argument are assigned first, then those after it,
+// with the remaining tokens assigned to the variadic argument.
+// Positions holds the index of each token in the arguments parsed, to locate errors.
+func assignArgs(args []*Arg, tokens []string, positions []int) (map[*Arg][]string, error) {
+	ret := make(map[*Arg][]string, len(args))
+
+	v := -1
+	for i, arg := range args {
+		if arg.Variadic {
+			v = i
+		}
+	}
+
+	if v == -1 {
+		i := 0
+		for ; i < len(args) && i < len(tokens); i++ {
+			ret[args[i]] = tokens[i : i+1]
+		}
+		if i < len(args) && args[i].Required {
+			return nil, &MissingArgError{position: noPosition, Arg: args[i]}
+		}
+		if i < len(tokens) {
+			return nil, &UnexpectedArgError{position{Token: tokens[i], Pos: positions[i]}}
+		}
+		return ret, nil
+	}
+
+	// Variadic arguments only exist with required arguments, see FlagSet.AddArg.
+	before, after := args[:v], args[v+1:]
+	need := len(before) + len(after)
+	if args[v].Required {
+		need++
+	}
+	if len(tokens) < need {
+		// Report the first argument in order without a value.
+		missing := args[v]
+		if len(tokens) < len(before) {
+			missing = before[len(tokens)]
+		} else if !missing.Required {
+			missing = after[len(tokens)-len(before)]
+		}
+		return nil, &MissingArgError{position: noPosition, Arg: missing}
+	}
+
+	for i, arg := range before {
+		ret[arg] = tokens[i : i+1]
+	}
+	n := len(tokens) - len(after)
+	for i, arg := range after {
+		ret[arg] = tokens[n+i : n+i+1]
+	}
+	if n > len(before) {
+		ret[args[v]] = tokens[len(before):n]
+	}
+
+	return ret, nil
+}
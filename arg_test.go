@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArg_usage(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  *Arg
+		want string
+	}{
+		{"required", NewArg("SRC", "", true), "SRC"},
+		{"optional", NewArg("DEST", "", false), "[DEST]"},
+		{"variadic", NewVariadicArg("FILE", "", true), "FILE..."},
+		{"optional variadic", NewVariadicArg("FILE", "", false), "[FILE...]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.arg.usage(); got != tt.want {
+				t.Errorf("Arg.usage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_assignArgs(t *testing.T) {
+	src := NewVariadicArg("SRC", "", true)
+	dest := NewArg("DEST", "", true)
+	opt := NewArg("OPT", "", false)
+	files := NewVariadicArg("FILE", "", false)
+
+	tests := []struct {
+		name    string
+		args    []*Arg
+		tokens  []string
+		want    map[*Arg][]string
+		wantErr string
+	}{
+		{"fixed", []*Arg{dest, opt}, []string{"a", "b"}, map[*Arg][]string{dest: {"a"}, opt: {"b"}}, ""},
+		{"fixed optional missing", []*Arg{dest, opt}, []string{"a"}, map[*Arg][]string{dest: {"a"}}, ""},
+		{"fixed missing", []*Arg{dest, opt}, nil, nil, "missing argument DEST"},
+		{"fixed too many", []*Arg{dest}, []string{"a", "b"}, nil, `unexpected argument "b"`},
+		{"variadic first", []*Arg{src, dest}, []string{"a", "b", "c"}, map[*Arg][]string{src: {"a", "b"}, dest: {"c"}}, ""},
+		{"variadic missing", []*Arg{src, dest}, []string{"a"}, nil, "missing argument SRC"},
+		{"optional variadic empty", []*Arg{dest, files}, []string{"a"}, map[*Arg][]string{dest: {"a"}}, ""},
+		{"optional variadic middle", []*Arg{dest, files, opt}, []string{"a"}, nil, "missing argument OPT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positions := make([]int, len(tt.tokens))
+			for i := range positions {
+				positions[i] = i
+			}
+			got, err := assignArgs(tt.args, tt.tokens, positions)
+			if len(tt.wantErr) > 0 {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("assignArgs() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("assignArgs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assignArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseArgs_positional(t *testing.T) {
+	defer unsetenv("POSIXLY_CORRECT")()
+
+	fs := NewFlagSet()
+	fs.AddNewFlag('f', "force", "", false)
+	src := NewVariadicArg("SRC", "", true)
+	fs.AddArg(src)
+	n, _ := fs.AddNewArg("N", "", true)
+	n.Type = TypeInt
+
+	got, err := NewParser().ParseArgs(fs, []string{"a", "-f", "b", "3"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	if vals := got.ArgValues("SRC"); !reflect.DeepEqual(vals, []string{"a", "b"}) {
+		t.Errorf("CommandLine.ArgValues() = %v, want [a b]", vals)
+	}
+	if v, ok := got.ArgValue("N"); v != 3 || !ok {
+		t.Errorf("CommandLine.ArgValue() = %v, %v, want 3, true", v, ok)
+	}
+	if v, _ := got.ArgValue("SRC"); !reflect.DeepEqual(v, []interface{}{"a", "b"}) {
+		t.Errorf("CommandLine.ArgValue() = %v, want [a b]", v)
+	}
+	if v, ok := got.Arg("SRC"); v != "a" || !ok {
+		t.Errorf("CommandLine.Arg() = %v, %v, want a, true", v, ok)
+	}
+
+	if _, err := NewParser().ParseArgs(fs, []string{"a", "b"}); err == nil {
+		t.Errorf("Parser.ParseArgs() error = %v, wantErr true for invalid int", err)
+	}
+	if _, err := NewParser().ParseArgs(fs, []string{"3"}); err == nil {
+		t.Errorf("Parser.ParseArgs() error = %v, wantErr true for missing argument", err)
+	}
+}
+
+func TestParser_ParseArgs_positionalErrors(t *testing.T) {
+	defer unsetenv("POSIXLY_CORRECT")()
+
+	fs := NewFlagSet()
+	fs.AddNewFlag('f', "force", "", false)
+	fs.AddNewArg("SRC", "", true)
+
+	_, err := NewParser().ParseArgs(fs, []string{"a", "-f", "b"})
+	if e, ok := err.(*UnexpectedArgError); !ok || e.Token != "b" || e.Pos != 2 {
+		t.Errorf("Parser.ParseArgs() error = %#v, want UnexpectedArgError at 2", err)
+	}
+
+	_, err = NewParser().ParseArgs(fs, []string{"-f"})
+	if e, ok := err.(*MissingArgError); !ok || e.Token != "" || e.Pos != 1 {
+		t.Errorf("Parser.ParseArgs() error = %#v, want MissingArgError at 1", err)
+	}
+}
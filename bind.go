@@ -0,0 +1,412 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Tag is the struct field tag key read by AddStruct.
+const Tag = "cli"
+
+var (
+	valueType    = reflect.TypeOf((*Value)(nil)).Elem()
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	urlType      = reflect.TypeOf((*url.URL)(nil))
+	ipType       = reflect.TypeOf(net.IP(nil))
+	regexpType   = reflect.TypeOf((*regexp.Regexp)(nil))
+)
+
+// NewFlagSetFromStruct constructs a new FlagSet from the tagged fields of the struct pointed to by v.
+// See AddStruct for the supported tags and field types.
+func NewFlagSetFromStruct(v interface{}) (*FlagSet, error) {
+	f := NewFlagSet()
+	if err := f.AddStruct(v); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// AddStruct adds a Flag for each tagged field of the struct pointed to by v.
+// The values parsed for each Flag are converted and stored in the fields once parsing succeeds,
+// replacing their contents; fields of flags that are not set keep their values.
+//
+// Fields are tagged with comma separated options, ie.
+//
+//	Output string `cli:"short=o,long=output,required,desc=the output file"`
+//
+// The options are:
+//
+//	short=c    the short flag
+//	long=name  the long flag, defaults to the lowercase field name if no short flag is set
+//	arg=NAME   the argument name for the help formatter
+//	env=NAME   the environment variable used if the flag is not parsed
+//	default=v  the value used if the flag is not set
+//	required   the flag is required
+//	count      an int field counts the times the flag is parsed, ie. -vvv
+//	bytes      a uint64 field is parsed as a byte size, ie. 10MiB
+//	desc=text  the flag description, must be the last option
+//
+// Fields tagged with "-" are skipped, untagged struct fields are added recursively.
+// Supported field types are string, int, int64, uint, uint64, float64, bool, time.Duration,
+// time.Time, *url.URL, net.IP, *regexp.Regexp, slices of these (repeatable flags),
+// and any type with a pointer implementing Value.
+// Bool fields are flags without an argument.
+func (f *FlagSet) AddStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return &InvalidFlagDefinitionError{Reason: fmt.Sprintf("%T is not a pointer to a struct", v)}
+	}
+	return f.addStruct(rv.Elem())
+}
+
+func (f *FlagSet) addStruct(rv reflect.Value) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		tag, tagged := field.Tag.Lookup(Tag)
+		if tag == "-" {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if !tagged {
+			// Groups of flags in nested structs.
+			if field.Type.Kind() == reflect.Struct && field.Type != timeType && fv.CanSet() {
+				if err := f.addStruct(fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if !fv.CanSet() {
+			return &InvalidFlagDefinitionError{Reason: fmt.Sprintf("tagged field %v is not exported", field.Name)}
+		}
+
+		flag, err := newFieldFlag(field, fv, tag)
+		if err != nil {
+			return err
+		}
+		if err := f.AddFlag(flag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fieldTag represents the parsed options of a field tag.
+type fieldTag struct {
+	short    rune
+	long     string
+	argName  string
+	env      string
+	def      string
+	desc     string
+	required bool
+	count    bool
+	bytes    bool
+}
+
+func parseFieldTag(field reflect.StructField, tag string) (*fieldTag, error) {
+	t := new(fieldTag)
+
+	for len(tag) > 0 {
+		var opt string
+		if strings.HasPrefix(tag, "desc=") {
+			// The description may contain commas.
+			opt, tag = tag, ""
+		} else if i := strings.IndexByte(tag, ','); i > -1 {
+			opt, tag = tag[:i], tag[i+1:]
+		} else {
+			opt, tag = tag, ""
+		}
+
+		key, val := opt, ""
+		if i := strings.IndexByte(opt, '='); i > -1 {
+			key, val = opt[:i], opt[i+1:]
+		}
+
+		switch key {
+		case "short":
+			r, n := utf8.DecodeRuneInString(val)
+			if n == 0 || n != len(val) {
+				return nil, &InvalidFlagDefinitionError{Reason: fmt.Sprintf(`field %v short flag "%v" is not a single rune`, field.Name, val)}
+			}
+			t.short = r
+		case "long":
+			t.long = val
+		case "arg":
+			t.argName = val
+		case "env":
+			t.env = val
+		case "default":
+			t.def = val
+		case "desc":
+			t.desc = val
+		case "required":
+			t.required = true
+		case "count":
+			t.count = true
+		case "bytes":
+			t.bytes = true
+		case "":
+		default:
+			return nil, &InvalidFlagDefinitionError{Reason: fmt.Sprintf(`field %v has unknown tag option "%v"`, field.Name, key)}
+		}
+	}
+
+	if t.short == 0 && len(t.long) == 0 {
+		t.long = strings.ToLower(field.Name)
+	}
+	return t, nil
+}
+
+// newFieldFlag constructs a Flag storing values parsed in the field value.
+func newFieldFlag(field reflect.StructField, fv reflect.Value, tag string) (*Flag, error) {
+	t, err := parseFieldTag(field, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	flag := NewFlag(t.short, t.long, t.desc, true)
+	flag.Required = t.required
+	flag.EnvVar = t.env
+	flag.Default = t.def
+	if len(t.argName) > 0 {
+		flag.ArgName = t.argName
+	}
+
+	switch {
+	case fv.Addr().Type().Implements(valueType):
+		flag.Value = fv.Addr().Interface().(Value)
+	case t.count:
+		if fv.Kind() != reflect.Int {
+			return nil, &InvalidFlagDefinitionError{Flag: flag, Reason: fmt.Sprintf("count field %v is not an int", field.Name)}
+		}
+		flag.HasArg = false
+		flag.Repeatable = true
+		flag.Value = &countValue{field: fv}
+	case fv.Kind() == reflect.Bool:
+		flag.HasArg = false
+		flag.Value = &fieldValue{typ: TypeBool, field: fv}
+	case fv.Kind() == reflect.Slice && fv.Type() != ipType:
+		typ, err := fieldValueType(field, fv.Type().Elem(), t)
+		if err != nil {
+			return nil, err
+		}
+		flag.Repeatable = true
+		flag.Value = &sliceValue{typ: typ, field: fv}
+	default:
+		typ, err := fieldValueType(field, fv.Type(), t)
+		if err != nil {
+			return nil, err
+		}
+		flag.Value = &fieldValue{typ: typ, field: fv}
+	}
+
+	return flag, nil
+}
+
+// fieldValueType returns the ValueType values for the field type are converted from.
+func fieldValueType(field reflect.StructField, rt reflect.Type, t *fieldTag) (ValueType, error) {
+	switch rt {
+	case durationType:
+		return TypeDuration, nil
+	case timeType:
+		return TypeTime, nil
+	case urlType:
+		return TypeURL, nil
+	case ipType:
+		return TypeIP, nil
+	case regexpType:
+		return TypeRegexp, nil
+	}
+
+	switch rt.Kind() {
+	case reflect.String:
+		return TypeString, nil
+	case reflect.Int:
+		return TypeInt, nil
+	case reflect.Int64:
+		return TypeInt64, nil
+	case reflect.Uint:
+		return TypeUint, nil
+	case reflect.Uint64:
+		if t.bytes {
+			return TypeBytes, nil
+		}
+		return TypeUint, nil
+	case reflect.Float64:
+		return TypeFloat64, nil
+	case reflect.Bool:
+		return TypeBool, nil
+	}
+
+	return 0, &InvalidFlagDefinitionError{Reason: fmt.Sprintf("field %v has unsupported type %v", field.Name, field.Type)}
+}
+
+// deferredValue is implemented by Values holding parsed values until parsing succeeds.
+type deferredValue interface {
+	// reset discards the values held, before parsing.
+	reset()
+	// commit stores the values held, after parsing succeeded.
+	commit()
+}
+
+// resetValues discards the values held by the deferred Values of the flags,
+// except those of flags in the FlagSet already parsed (nil for none).
+func (f *FlagSet) resetValues(parsed *FlagSet) {
+	for _, flag := range f.declared {
+		if parsed != nil && parsed.contains(flag) {
+			continue
+		}
+		if v, ok := flag.Value.(deferredValue); ok {
+			v.reset()
+		}
+	}
+}
+
+// commitValues stores the values held by the deferred Values of the flags.
+func (f *FlagSet) commitValues() {
+	for _, flag := range f.declared {
+		if v, ok := flag.Value.(deferredValue); ok {
+			v.commit()
+		}
+	}
+}
+
+// fieldValue is a Value storing a converted value in a struct field.
+type fieldValue struct {
+	typ   ValueType
+	field reflect.Value
+	value reflect.Value // the parsed value, invalid if not set
+}
+
+func (v *fieldValue) String() string {
+	if v.value.IsValid() {
+		return fmt.Sprint(v.value.Interface())
+	}
+	return fmt.Sprint(v.field.Interface())
+}
+
+func (v *fieldValue) Set(s string) error {
+	val, err := convert(v.typ, s)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(val)
+	if !rv.Type().ConvertibleTo(v.field.Type()) {
+		return fmt.Errorf("cannot store %v in %v", v.typ, v.field.Type())
+	}
+	v.value = rv.Convert(v.field.Type())
+	return nil
+}
+
+func (v *fieldValue) Type() string {
+	return v.typ.String()
+}
+
+func (v *fieldValue) reset() {
+	v.value = reflect.Value{}
+}
+
+func (v *fieldValue) commit() {
+	if v.value.IsValid() {
+		v.field.Set(v.value)
+	}
+	v.reset()
+}
+
+// sliceValue is a Value collecting converted values in a struct slice field.
+type sliceValue struct {
+	typ    ValueType
+	field  reflect.Value
+	values reflect.Value // the parsed values, invalid if not set
+}
+
+func (v *sliceValue) String() string {
+	if v.values.IsValid() {
+		return fmt.Sprint(v.values.Interface())
+	}
+	return fmt.Sprint(v.field.Interface())
+}
+
+func (v *sliceValue) Set(s string) error {
+	val, err := convert(v.typ, s)
+	if err != nil {
+		return err
+	}
+	elem := v.field.Type().Elem()
+	rv := reflect.ValueOf(val)
+	if !rv.Type().ConvertibleTo(elem) {
+		return fmt.Errorf("cannot store %v in %v", v.typ, elem)
+	}
+	if !v.values.IsValid() {
+		v.values = reflect.MakeSlice(v.field.Type(), 0, 1)
+	}
+	v.values = reflect.Append(v.values, rv.Convert(elem))
+	return nil
+}
+
+func (v *sliceValue) Type() string {
+	return v.typ.String()
+}
+
+func (v *sliceValue) reset() {
+	v.values = reflect.Value{}
+}
+
+func (v *sliceValue) commit() {
+	if v.values.IsValid() {
+		v.field.Set(v.values)
+	}
+	v.reset()
+}
+
+// countValue is a Value counting the times a flag is parsed in an int struct field.
+type countValue struct {
+	field reflect.Value
+	count int64 // the times parsed
+}
+
+func (v *countValue) String() string {
+	if v.count > 0 {
+		return strconv.FormatInt(v.count, 10)
+	}
+	return strconv.FormatInt(v.field.Int(), 10)
+}
+
+func (v *countValue) Set(s string) error {
+	if s != "true" {
+		return errors.New("count flags do not accept an argument")
+	}
+	v.count++
+	return nil
+}
+
+func (v *countValue) Type() string {
+	return "count"
+}
+
+func (v *countValue) reset() {
+	v.count = 0
+}
+
+func (v *countValue) commit() {
+	if v.count > 0 {
+		v.field.SetInt(v.count)
+	}
+	v.reset()
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type bindNetwork struct {
+	Port    int           `cli:"short=p,long=port,desc=the port, to listen on"`
+	Timeout time.Duration `cli:"long=timeout,default=30s"`
+}
+
+type bindOptions struct {
+	Output  string     `cli:"short=o,long=output,required,arg=FILE,desc=the output file"`
+	Verbose int        `cli:"short=v,count"`
+	Force   bool       `cli:"short=f"`
+	Tags    []string   `cli:"long=tag"`
+	Max     uint64     `cli:"long=max,bytes"`
+	Level   levelValue `cli:"long=level"`
+	Skipped string     `cli:"-"`
+	Untyped string
+	Network bindNetwork
+}
+
+func TestFlagSet_AddStruct(t *testing.T) {
+	opts := new(bindOptions)
+	fs, err := NewFlagSetFromStruct(opts)
+	if err != nil {
+		t.Fatalf("NewFlagSetFromStruct() error = %v", err)
+	}
+
+	if flag, ok := fs.Lookup("output"); !ok || !flag.Required || flag.Short != 'o' || flag.ArgName != "FILE" {
+		t.Errorf("FlagSet.Lookup() = %v, want required output flag", flag)
+	}
+	if flag, ok := fs.Lookup("port"); !ok || flag.Description != "the port, to listen on" {
+		t.Errorf("FlagSet.Lookup() = %v, want port flag with description", flag)
+	}
+	if _, ok := fs.Lookup("skipped"); ok {
+		t.Errorf("FlagSet.Lookup() found skipped field")
+	}
+
+	args := []string{
+		"-vv", "-o", "out.txt", "-f", "--tag=a", "--tag", "b",
+		"--max=2KiB", "--level=warn", "-p", "8080", "arg",
+	}
+	if _, err := NewParser().ParseArgs(fs, args); err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+
+	want := &bindOptions{
+		Output:  "out.txt",
+		Verbose: 2,
+		Force:   true,
+		Tags:    []string{"a", "b"},
+		Max:     2048,
+		Level:   levelValue{"warn", 1},
+		Network: bindNetwork{8080, 30 * time.Second},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("Parser.ParseArgs() = %+v, want %+v", opts, want)
+	}
+}
+
+func TestFlagSet_AddStruct_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"not a pointer", bindOptions{}},
+		{"not a struct", new(string)},
+		{"unexported", &struct {
+			f string `cli:"long=field"`
+		}{}},
+		{"unsupported type", &struct {
+			F complex64 `cli:"long=field"`
+		}{}},
+		{"unknown option", &struct {
+			F string `cli:"long=field,other"`
+		}{}},
+		{"count not int", &struct {
+			F string `cli:"short=f,count"`
+		}{}},
+		{"duplicate", &struct {
+			A string `cli:"short=a"`
+			B string `cli:"short=a"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewFlagSet().AddStruct(tt.v); err == nil {
+				t.Errorf("FlagSet.AddStruct() error = %v, wantErr true", err)
+			}
+		})
+	}
+}
+
+func TestFlagSet_AddStruct_invalidValue(t *testing.T) {
+	opts := new(bindOptions)
+	fs, _ := NewFlagSetFromStruct(opts)
+
+	if _, err := NewParser().ParseArgs(fs, []string{"-o", "x", "--port", "eighty"}); err == nil {
+		t.Errorf("Parser.ParseArgs() error = %v, wantErr true", err)
+	}
+}
+
+func TestFlagSet_AddStruct_reuse(t *testing.T) {
+	opts := &bindOptions{Tags: []string{"default"}, Output: "old"}
+	fs, _ := NewFlagSetFromStruct(opts)
+
+	if _, err := NewParser().ParseArgs(fs, []string{"-o", "x", "-vv", "--tag", "a"}); err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	if _, err := NewParser().ParseArgs(fs, []string{"-o", "y", "-v", "--tag", "b"}); err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	if opts.Output != "y" || opts.Verbose != 1 || !reflect.DeepEqual(opts.Tags, []string{"b"}) {
+		t.Errorf("Parser.ParseArgs() = %+v, want Output y, Verbose 1, Tags [b]", opts)
+	}
+
+	// A failed parse leaves the fields untouched.
+	if _, err := NewParser().ParseArgs(fs, []string{"-o", "z", "-vvv", "--tag", "c", "--port", "eighty"}); err == nil {
+		t.Fatalf("Parser.ParseArgs() error = %v, wantErr true", err)
+	}
+	if opts.Output != "y" || opts.Verbose != 1 || !reflect.DeepEqual(opts.Tags, []string{"b"}) {
+		t.Errorf("Parser.ParseArgs() = %+v, want Output y, Verbose 1, Tags [b]", opts)
+	}
+}
+
+func TestParser_Execute_bind(t *testing.T) {
+	var rootOpts struct {
+		Verbose int `cli:"short=v,count"`
+	}
+	var subOpts struct {
+		Name string `cli:"long=name"`
+	}
+
+	root := NewCommand("tool", "", nil)
+	if err := root.PersistentFlags.AddStruct(&rootOpts); err != nil {
+		t.Fatal(err)
+	}
+	sub := NewCommand("sub", "", func(CommandLine) error { return nil })
+	if err := sub.Flags.AddStruct(&subOpts); err != nil {
+		t.Fatal(err)
+	}
+	root.AddCommand(sub)
+
+	if err := NewParser().Execute(root, []string{"-v", "sub", "--name", "x", "-v"}); err != nil {
+		t.Fatalf("Parser.Execute() error = %v", err)
+	}
+	if rootOpts.Verbose != 2 || subOpts.Name != "x" {
+		t.Errorf("Parser.Execute() = %+v, %+v, want Verbose 2, Name x", rootOpts, subOpts)
+	}
+}
@@ -1,41 +1,196 @@
 package cli
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Source represents where the value of a Flag came from.
+type Source int
+
+const (
+	SourceNone    Source = iota // the flag was not set
+	SourceArgs                  // the flag was parsed from the arguments
+	SourceEnv                   // the flag was set from an environment variable
+	SourceConfig                // the flag was set from a config file
+	SourceDefault               // the flag was set to its default value
+)
+
+var sourceNames = [...]string{
+	SourceNone:    "none",
+	SourceArgs:    "args",
+	SourceEnv:     "env",
+	SourceConfig:  "config",
+	SourceDefault: "default",
+}
+
+// String returns the name of the Source.
+func (s Source) String() string {
+	if s < 0 || int(s) >= len(sourceNames) {
+		return "Source(" + strconv.Itoa(int(s)) + ")"
+	}
+	return sourceNames[s]
+}
 
 type commandLine struct {
-	args   []string // arguments
-	flags  []*Flag  // parsed flags
-	values map[*Flag]string
+	args    []string              // arguments
+	argPos  []int                 // the index of each argument in the arguments parsed
+	dashed  bool                  // true if "--" was parsed
+	dash    int                   // the number of arguments before "--"
+	flags   []*Flag               // parsed flags, repeated for each occurrence
+	values  map[*Flag][]string    // values parsed, in order
+	typed   map[*Flag]interface{} // last value converted to the Flag type
+	sources map[*Flag]Source      // where the value of each Flag came from
+
+	argSpecs   []*Arg                   // the positional arguments
+	positional map[string][]string      // arguments by positional argument name
+	converted  map[string][]interface{} // arguments converted to the positional argument type
+
+	command *Command // the command parsed (nil if not parsing commands)
 }
 
-func (c *commandLine) addArg(arg string) {
+func (c *commandLine) addArg(arg string, pos int) {
 	c.args = append(c.args, arg)
+	c.argPos = append(c.argPos, pos)
 }
 
 func (c *commandLine) processValue(flag *Flag, value string) error {
-	if val, ok := c.values[flag]; ok {
-		return fmt.Errorf(`%v already has a argument "%v"`, flag, val)
+	if vals, ok := c.values[flag]; ok && !flag.Repeatable {
+		return &DuplicateFlagError{position: noPosition, Flag: flag, Value: vals[0]}
 	}
 	if !flag.HasArg {
-		return fmt.Errorf("%v does not accept an argument", flag)
+		return &UnexpectedValueError{position: noPosition, Flag: flag}
+	}
+
+	// Choices are stored as declared, ie. "json" for "JSON" ignoring case.
+	choice, err := flag.choose(value)
+	if err != nil {
+		return c.invalidValue(flag, value, err)
+	}
+	value = choice
+
+	// Custom values parse and store the argument themselves.
+	if flag.Value != nil {
+		if err := flag.Value.Set(value); err != nil {
+			return c.invalidValue(flag, value, err)
+		}
+		c.values[flag] = append(c.values[flag], value)
+		return nil
+	}
+
+	v, err := convert(flag.Type, value)
+	if err != nil {
+		return c.invalidValue(flag, value, err)
+	}
+
+	c.values[flag] = append(c.values[flag], value)
+	c.typed[flag] = v
+	return nil
+}
+
+// processFlag records a Flag parsed from the source.
+// Custom values of flags without an argument are set to "true",
+// as with boolean flags in the standard library.
+func (c *commandLine) processFlag(flag *Flag, source Source) error {
+	c.flags = append(c.flags, flag)
+	c.setSource(flag, source)
+
+	if !flag.HasArg && flag.Value != nil {
+		if err := flag.Value.Set("true"); err != nil {
+			return c.invalidValue(flag, "true", err)
+		}
 	}
-	c.values[flag] = value
 	return nil
 }
 
+// processBool records a Negatable Flag set to true or false from the source.
+// The value is stored as "true" or "false", so an explicit false is distinguished from an absent flag.
+func (c *commandLine) processBool(flag *Flag, set bool, source Source) error {
+	c.flags = append(c.flags, flag)
+	c.setSource(flag, source)
+
+	val := strconv.FormatBool(set)
+	c.values[flag] = append(c.values[flag], val)
+
+	if flag.Value != nil {
+		if err := flag.Value.Set(val); err != nil {
+			return c.invalidValue(flag, val, err)
+		}
+	}
+	return nil
+}
+
+// invalidValue returns an InvalidValueError for the Flag from its current source.
+func (c *commandLine) invalidValue(flag *Flag, value string, err error) error {
+	return &InvalidValueError{
+		position: noPosition,
+		Flag:     flag,
+		Value:    value,
+		Source:   c.sources[flag],
+		Err:      err,
+	}
+}
+
+// processDefault sets the default value of a Flag that was not parsed.
+// The Flag is not recorded as parsed, so it is not counted.
+func (c *commandLine) processDefault(flag *Flag) error {
+	if len(flag.Default) == 0 {
+		return nil
+	}
+
+	if flag.HasArg {
+		c.setSource(flag, SourceDefault)
+		return c.processValue(flag, flag.Default)
+	}
+
+	// Flags without an argument have a boolean default.
+	set, err := strconv.ParseBool(flag.Default)
+	if err != nil {
+		return &InvalidValueError{position: noPosition, Flag: flag, Value: flag.Default, Source: SourceDefault}
+	}
+	if !set {
+		return nil
+	}
+	c.setSource(flag, SourceDefault)
+	if flag.Negatable {
+		c.values[flag] = []string{"true"}
+	}
+	if flag.Value != nil {
+		if err := flag.Value.Set("true"); err != nil {
+			return c.invalidValue(flag, "true", err)
+		}
+	}
+	return nil
+}
+
+func (c *commandLine) setSource(flag *Flag, source Source) {
+	if c.sources == nil {
+		c.sources = make(map[*Flag]Source)
+	}
+	c.sources[flag] = source
+}
+
 func (c *commandLine) needsValue(flag *Flag) bool {
 	if !flag.HasArg {
 		return false
 	}
 
-	_, ok := c.values[flag]
-	return !ok
+	return len(c.values[flag]) < c.Count(flag)
 }
 
 // Value returns the argument parsed for the specified Flag.
+// Returns the last argument if the Flag was repeated.
 func (c *commandLine) Value(flag *Flag) (string, bool) {
-	if val, ok := c.values[flag]; ok {
-		return val, ok
+	if vals, ok := c.values[flag]; ok {
+		return vals[len(vals)-1], ok
+	}
+	if _, ok := c.sources[flag]; ok {
+		return "", true
 	}
 	for _, f := range c.flags {
 		if flag == f {
@@ -45,15 +200,449 @@ func (c *commandLine) Value(flag *Flag) (string, bool) {
 	return "", false
 }
 
+// Source returns where the value of the specified Flag came from.
+func (c *commandLine) Source(flag *Flag) Source {
+	return c.sources[flag]
+}
+
+// Values returns all the arguments parsed for the specified Flag, in order.
+func (c *commandLine) Values(flag *Flag) []string {
+	vals, ok := c.values[flag]
+	if !ok {
+		return nil
+	}
+
+	ret := make([]string, len(vals))
+	copy(ret, vals)
+	return ret
+}
+
+// Count returns the number of times the specified Flag was parsed.
+func (c *commandLine) Count(flag *Flag) int {
+	n := 0
+	for _, f := range c.flags {
+		if flag == f {
+			n++
+		}
+	}
+	return n
+}
+
+// ArgsBeforeDash returns the arguments parsed before "--", all the arguments if there was none.
+func (c *commandLine) ArgsBeforeDash() []string {
+	if !c.dashed {
+		return c.args
+	}
+	// Limit the capacity so appending does not overwrite the arguments after "--".
+	return c.args[:c.dash:c.dash]
+}
+
+// ArgsAfterDash returns the arguments after "--" as passed, nil if there was none.
+func (c *commandLine) ArgsAfterDash() []string {
+	if !c.dashed {
+		return nil
+	}
+	return c.args[c.dash:]
+}
+
 // Args returns the arguments parsed.
 func (c *commandLine) Args() []string {
 	return c.args
 }
 
+// processArgs assigns the arguments parsed to the positional arguments.
+func (c *commandLine) processArgs(args []*Arg) error {
+	assigned, err := assignArgs(args, c.args, c.argPos)
+	if err != nil {
+		return err
+	}
+
+	c.argSpecs = args
+	c.positional = make(map[string][]string, len(assigned))
+	c.converted = make(map[string][]interface{}, len(assigned))
+
+	// The arguments are assigned in order, i is the index of the argument converted.
+	i := 0
+	for _, arg := range args {
+		vals, ok := assigned[arg]
+		if !ok {
+			continue
+		}
+
+		for _, val := range vals {
+			pos := position{val, c.argPos[i]}
+			i++
+
+			if arg.Value != nil {
+				if err := arg.Value.Set(val); err != nil {
+					return &InvalidValueError{position: pos, Arg: arg, Value: val, Source: SourceArgs, Err: err}
+				}
+				continue
+			}
+
+			v, err := convert(arg.Type, val)
+			if err != nil {
+				return &InvalidValueError{position: pos, Arg: arg, Value: val, Source: SourceArgs, Err: err}
+			}
+			c.converted[arg.Name] = append(c.converted[arg.Name], v)
+		}
+		c.positional[arg.Name] = vals
+	}
+
+	return nil
+}
+
+// Arg returns the argument parsed for the named positional argument.
+// Returns the first argument if the positional argument is variadic.
+func (c *commandLine) Arg(name string) (string, bool) {
+	if vals := c.positional[name]; len(vals) > 0 {
+		return vals[0], true
+	}
+	return "", false
+}
+
+// ArgValues returns all the arguments parsed for the named positional argument.
+func (c *commandLine) ArgValues(name string) []string {
+	vals, ok := c.positional[name]
+	if !ok {
+		return nil
+	}
+
+	ret := make([]string, len(vals))
+	copy(ret, vals)
+	return ret
+}
+
+// ArgValue returns the argument parsed for the named positional argument converted to its type.
+// Returns a []interface{} with all the converted arguments if the positional argument is variadic.
+func (c *commandLine) ArgValue(name string) (interface{}, bool) {
+	vals, ok := c.converted[name]
+	if !ok {
+		return nil, false
+	}
+
+	for _, arg := range c.argSpecs {
+		if arg.Name == name && arg.Variadic {
+			ret := make([]interface{}, len(vals))
+			copy(ret, vals)
+			return ret, true
+		}
+	}
+	return vals[0], true
+}
+
+// Command returns the Command parsed, or nil if not parsing commands.
+func (c *commandLine) Command() *Command {
+	return c.command
+}
+
+// typedValue returns the value of the Flag converted to the specified type.
+// Values are converted when parsed if the Flag has the same type,
+// otherwise the raw value is converted now.
+func (c *commandLine) typedValue(flag *Flag, typ ValueType) (interface{}, bool) {
+	if flag.Type == typ && flag.Value == nil {
+		v, ok := c.typed[flag]
+		return v, ok
+	}
+
+	val, ok := c.Value(flag)
+	if !ok || !flag.HasArg {
+		return nil, false
+	}
+	v, err := convert(typ, val)
+	if err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// Int returns the argument parsed for the specified Flag as an int.
+func (c *commandLine) Int(flag *Flag) (int, bool) {
+	v, ok := c.typedValue(flag, TypeInt)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+// Int64 returns the argument parsed for the specified Flag as an int64.
+func (c *commandLine) Int64(flag *Flag) (int64, bool) {
+	v, ok := c.typedValue(flag, TypeInt64)
+	if !ok {
+		return 0, false
+	}
+	return v.(int64), true
+}
+
+// Uint returns the argument parsed for the specified Flag as a uint.
+func (c *commandLine) Uint(flag *Flag) (uint, bool) {
+	v, ok := c.typedValue(flag, TypeUint)
+	if !ok {
+		return 0, false
+	}
+	return v.(uint), true
+}
+
+// Float64 returns the argument parsed for the specified Flag as a float64.
+func (c *commandLine) Float64(flag *Flag) (float64, bool) {
+	v, ok := c.typedValue(flag, TypeFloat64)
+	if !ok {
+		return 0, false
+	}
+	return v.(float64), true
+}
+
+// Bool returns the argument parsed for the specified Flag as a bool.
+// Flags without an argument are true if they were parsed,
+// Negatable flags are false and ok if negated.
+func (c *commandLine) Bool(flag *Flag) (bool, bool) {
+	if flag.Negatable {
+		vals := c.values[flag]
+		if len(vals) == 0 {
+			return false, false
+		}
+		return vals[len(vals)-1] == "true", true
+	}
+	if !flag.HasArg {
+		_, ok := c.Value(flag)
+		return ok, ok
+	}
+
+	v, ok := c.typedValue(flag, TypeBool)
+	if !ok {
+		return false, false
+	}
+	return v.(bool), true
+}
+
+// Duration returns the argument parsed for the specified Flag as a time.Duration.
+func (c *commandLine) Duration(flag *Flag) (time.Duration, bool) {
+	v, ok := c.typedValue(flag, TypeDuration)
+	if !ok {
+		return 0, false
+	}
+	return v.(time.Duration), true
+}
+
+// Time returns the argument parsed for the specified Flag as a time.Time.
+func (c *commandLine) Time(flag *Flag) (time.Time, bool) {
+	v, ok := c.typedValue(flag, TypeTime)
+	if !ok {
+		return time.Time{}, false
+	}
+	return v.(time.Time), true
+}
+
+// URL returns the argument parsed for the specified Flag as a *url.URL.
+func (c *commandLine) URL(flag *Flag) (*url.URL, bool) {
+	v, ok := c.typedValue(flag, TypeURL)
+	if !ok {
+		return nil, false
+	}
+	return v.(*url.URL), true
+}
+
+// IP returns the argument parsed for the specified Flag as a net.IP.
+func (c *commandLine) IP(flag *Flag) (net.IP, bool) {
+	v, ok := c.typedValue(flag, TypeIP)
+	if !ok {
+		return nil, false
+	}
+	return v.(net.IP), true
+}
+
+// Regexp returns the argument parsed for the specified Flag as a *regexp.Regexp.
+func (c *commandLine) Regexp(flag *Flag) (*regexp.Regexp, bool) {
+	v, ok := c.typedValue(flag, TypeRegexp)
+	if !ok {
+		return nil, false
+	}
+	return v.(*regexp.Regexp), true
+}
+
+// Bytes returns the argument parsed for the specified Flag as a byte size.
+func (c *commandLine) Bytes(flag *Flag) (uint64, bool) {
+	v, ok := c.typedValue(flag, TypeBytes)
+	if !ok {
+		return 0, false
+	}
+	return v.(uint64), true
+}
+
 // CommandLine represents the parsed results of a Parser.
 type CommandLine interface {
 	// Value returns the value parsed for the specified flag.
 	// If the flag was not parsed
 	Value(flag *Flag) (string, bool)
+	// Values returns all the values parsed for the specified repeatable flag, in order.
+	Values(flag *Flag) []string
+	// Count returns the number of times the specified flag was parsed.
+	Count(flag *Flag) int
+	// Source returns where the value of the specified flag came from.
+	Source(flag *Flag) Source
+	// Args returns all the positional arguments, including those after "--".
 	Args() []string
+	// ArgsBeforeDash returns the positional arguments before "--", all of them if there was none.
+	ArgsBeforeDash() []string
+	// ArgsAfterDash returns the arguments after "--" exactly as passed, nil if there was none.
+	ArgsAfterDash() []string
+	// Arg returns the value parsed for the named positional argument.
+	Arg(name string) (string, bool)
+	// ArgValues returns all the values parsed for the named positional argument.
+	ArgValues(name string) []string
+	// ArgValue returns the value parsed for the named positional argument converted to its type.
+	ArgValue(name string) (interface{}, bool)
+	// Command returns the deepest command parsed, or nil if not parsing commands.
+	Command() *Command
+
+	// Typed accessors return the value parsed for the specified flag converted
+	// to the requested type, and false if the flag was not parsed or the value
+	// could not be converted.
+	// Values for flags with a matching Type are converted while parsing.
+
+	Int(flag *Flag) (int, bool)
+	Int64(flag *Flag) (int64, bool)
+	Uint(flag *Flag) (uint, bool)
+	Float64(flag *Flag) (float64, bool)
+	Bool(flag *Flag) (bool, bool)
+	Duration(flag *Flag) (time.Duration, bool)
+	Time(flag *Flag) (time.Time, bool)
+	URL(flag *Flag) (*url.URL, bool)
+	IP(flag *Flag) (net.IP, bool)
+	Regexp(flag *Flag) (*regexp.Regexp, bool)
+	Bytes(flag *Flag) (uint64, bool)
+}
+
+// Command represents a command with its own flags and child commands,
+// ie. "remote" and "add" in "tool remote add --name x".
+type Command struct {
+	Name        string   // the command name
+	Aliases     []string // alternative names for the command
+	Description string   // the command description
+
+	Flags           *FlagSet // flags for this command only
+	PersistentFlags *FlagSet // flags for this command and all its descendants
+
+	// Run is called by Parser.Execute when this is the command parsed.
+	Run func(cmd CommandLine) error
+
+	parent   *Command
+	commands []*Command
+}
+
+// NewCommand constructs a new command with empty FlagSets.
+func NewCommand(name string, desc string, run func(cmd CommandLine) error) *Command {
+	return &Command{
+		Name:            name,
+		Description:     desc,
+		Flags:           NewFlagSet(),
+		PersistentFlags: NewFlagSet(),
+		Run:             run,
+	}
+}
+
+// AddCommand adds the specified child commands to the Command.
+// Returns an error if a name or alias is empty or already exists.
+func (c *Command) AddCommand(cmds ...*Command) error {
+	for _, cmd := range cmds {
+		if cmd.parent != nil {
+			return fmt.Errorf(`cli.Command: command "%v" already has a parent "%v"`, cmd.Name, cmd.parent.Name)
+		}
+		for _, name := range cmd.names() {
+			if len(name) == 0 {
+				return errors.New("cli.Command: command has no name")
+			}
+			if _, ok := c.Lookup(name); ok {
+				return fmt.Errorf(`cli.Command: command "%v" already exists in "%v"`, name, c.Path())
+			}
+		}
+
+		cmd.parent = c
+		c.commands = append(c.commands, cmd)
+	}
+	return nil
+}
+
+// Commands returns a slice with the child commands, in the order they were added.
+func (c *Command) Commands() []*Command {
+	cmds := make([]*Command, len(c.commands))
+	copy(cmds, c.commands)
+	return cmds
+}
+
+// Parent returns the parent Command, or nil if this is the root command.
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// Lookup returns the child Command with the name or alias specified.
+func (c *Command) Lookup(name string) (*Command, bool) {
+	for _, cmd := range c.commands {
+		for _, n := range cmd.names() {
+			if n == name {
+				return cmd, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Path returns the names of the commands from the root to this command, ie. "tool remote add".
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// names returns the name and aliases of the command.
+func (c *Command) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+// flagSet returns a FlagSet with the flags and positional arguments of this command,
+// and the persistent flags of this command and its ancestors.
+func (c *Command) flagSet() (*FlagSet, error) {
+	fs := NewFlagSet()
+	if c.Flags != nil {
+		fs.args = c.Flags.args
+	}
+
+	sets := []*FlagSet{c.Flags}
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		sets = append(sets, cmd.PersistentFlags)
+	}
+
+	for _, set := range sets {
+		if set == nil {
+			continue
+		}
+		// Use the closest environment variable prefix.
+		if len(fs.EnvPrefix) == 0 {
+			fs.EnvPrefix = set.EnvPrefix
+		}
+		// Flags are folded when added, so the sets must agree on the policy.
+		if len(set.declared) > 0 {
+			if fs.Naming == nil {
+				fs.Naming = set.naming()
+			} else if *set.naming() != *fs.Naming {
+				return nil, &InvalidFlagDefinitionError{
+					Flag:    set.declared[0],
+					Reason:  "inherited flags follow a different naming policy",
+					Command: c.Path(),
+				}
+			}
+		}
+		fs.GroupOrder = append(fs.GroupOrder, set.GroupOrder...)
+		fs.constraints = append(fs.constraints, set.constraints...)
+		for _, flag := range set.declared {
+			if err := fs.AddFlag(flag); err != nil {
+				if e, ok := err.(*InvalidFlagDefinitionError); ok {
+					e.Command = c.Path()
+				}
+				return nil, err
+			}
+		}
+	}
+	return fs, nil
 }
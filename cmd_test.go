@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -9,15 +11,15 @@ func Test_commandLine_addArg(t *testing.T) {
 	want := &commandLine{
 		args:   []string{"arg1", "arg2", "want"},
 		flags:  []*Flag(nil),
-		values: map[*Flag]string(nil),
+		values: map[*Flag][]string(nil),
 	}
 
 	c := &commandLine{
 		args:   []string{"arg1", "arg2"},
 		flags:  []*Flag(nil),
-		values: map[*Flag]string(nil),
+		values: map[*Flag][]string(nil),
 	}
-	if c.addArg("arg3"); reflect.DeepEqual(c, want) {
+	if c.addArg("arg3", 2); reflect.DeepEqual(c, want) {
 		t.Errorf("commandLine.addArg() = %v, want %v", c, want)
 	}
 }
@@ -27,9 +29,235 @@ func Test_commandLine_Args(t *testing.T) {
 	c := &commandLine{
 		args:   want,
 		flags:  []*Flag(nil),
-		values: map[*Flag]string(nil),
+		values: map[*Flag][]string(nil),
 	}
 	if got := c.Args(); !reflect.DeepEqual(got, want) {
 		t.Errorf("commandLine.Args() = %v, want %v", got, want)
 	}
 }
+
+func Test_commandLine_typed(t *testing.T) {
+	fi := NewFlag('i', "", "", true)
+	fi.Type = TypeInt
+	fs := NewFlag('s', "", "", true)
+	fb := NewFlag('b', "", "", false)
+
+	c := &commandLine{
+		values: make(map[*Flag][]string),
+		typed:  make(map[*Flag]interface{}),
+	}
+	if err := c.processValue(fi, "42"); err != nil {
+		t.Fatalf("commandLine.processValue() error = %v", err)
+	}
+	if err := c.processValue(fs, "7"); err != nil {
+		t.Fatalf("commandLine.processValue() error = %v", err)
+	}
+	c.flags = []*Flag{fi, fs, fb}
+
+	if got, ok := c.Int(fi); got != 42 || !ok {
+		t.Errorf("commandLine.Int() = %v, %v, want 42, true", got, ok)
+	}
+	if got, ok := c.Int64(fs); got != 7 || !ok {
+		t.Errorf("commandLine.Int64() = %v, %v, want 7, true", got, ok)
+	}
+	if got, ok := c.Duration(fs); ok {
+		t.Errorf("commandLine.Duration() = %v, %v, want 0, false", got, ok)
+	}
+	if got, ok := c.Bool(fb); !got || !ok {
+		t.Errorf("commandLine.Bool() = %v, %v, want true, true", got, ok)
+	}
+}
+
+func Test_commandLine_processValue_invalid(t *testing.T) {
+	f := NewFlag('i', "", "", true)
+	f.Type = TypeInt
+
+	c := &commandLine{
+		values: make(map[*Flag][]string),
+		typed:  make(map[*Flag]interface{}),
+	}
+	if err := c.processValue(f, "abc"); err == nil {
+		t.Errorf("commandLine.processValue() error = %v, wantErr true", err)
+	}
+	if _, ok := c.Value(f); ok {
+		t.Errorf("commandLine.Value() ok = true after invalid value")
+	}
+}
+
+func Test_commandLine_Values(t *testing.T) {
+	f := NewFlag('I', "", "", true)
+	f.Repeatable = true
+
+	c := &commandLine{
+		values: make(map[*Flag][]string),
+		typed:  make(map[*Flag]interface{}),
+	}
+	for _, val := range []string{"a", "b"} {
+		c.processFlag(f, SourceArgs)
+		if err := c.processValue(f, val); err != nil {
+			t.Fatalf("commandLine.processValue() error = %v", err)
+		}
+	}
+
+	want := []string{"a", "b"}
+	if got := c.Values(f); !reflect.DeepEqual(got, want) {
+		t.Errorf("commandLine.Values() = %v, want %v", got, want)
+	}
+	if got, _ := c.Value(f); got != "b" {
+		t.Errorf("commandLine.Value() = %v, want %v", got, "b")
+	}
+	if got := c.Count(f); got != 2 {
+		t.Errorf("commandLine.Count() = %v, want %v", got, 2)
+	}
+}
+
+func TestCommand_AddCommand(t *testing.T) {
+	root := NewCommand("tool", "", nil)
+	remote := NewCommand("remote", "", nil)
+	remote.Aliases = []string{"r"}
+
+	if err := root.AddCommand(remote); err != nil {
+		t.Fatalf("Command.AddCommand() error = %v", err)
+	}
+	if err := root.AddCommand(NewCommand("r", "", nil)); err == nil {
+		t.Errorf("Command.AddCommand() duplicate alias error = nil")
+	}
+	if err := NewCommand("other", "", nil).AddCommand(remote); err == nil {
+		t.Errorf("Command.AddCommand() existing parent error = nil")
+	}
+	if err := root.AddCommand(NewCommand("", "", nil)); err == nil {
+		t.Errorf("Command.AddCommand() no name error = nil")
+	}
+
+	if got, ok := root.Lookup("r"); got != remote || !ok {
+		t.Errorf("Command.Lookup() = %v, %v, want %v, true", got, ok, remote)
+	}
+}
+
+func TestParser_Execute(t *testing.T) {
+	var ran *Command
+	var got CommandLine
+	run := func(c CommandLine) error {
+		ran, got = c.Command(), c
+		return nil
+	}
+
+	root := NewCommand("tool", "", nil)
+	verbose, _ := root.PersistentFlags.AddNewFlag('v', "verbose", "", false)
+	remote := NewCommand("remote", "", nil)
+	add := NewCommand("add", "", run)
+	name, _ := add.Flags.AddNewRequiredFlag(0, "name", "", true)
+	root.AddCommand(remote)
+	remote.AddCommand(add)
+
+	tests := []struct {
+		name     string
+		args     []string
+		want     *Command
+		wantArgs []string
+		wantErr  bool
+	}{
+		{"subcommand", []string{"remote", "add", "--name", "x", "-v", "url"}, add, []string{"url"}, false},
+		{"persistent before command", []string{"-v", "remote", "add", "--name=x"}, add, []string{}, false},
+		{"missing required", []string{"remote", "add"}, nil, nil, true},
+		{"flag of child on parent", []string{"--name", "x", "remote", "add"}, nil, nil, true},
+		{"unknown command", []string{"remot"}, nil, nil, true},
+		{"not runnable", []string{"remote"}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran, got = nil, nil
+
+			err := NewParser().Execute(root, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.Execute() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if ran != tt.want {
+				t.Errorf("Parser.Execute() ran %v, want %v", ran.Path(), tt.want.Path())
+			}
+			if !reflect.DeepEqual(got.Args(), tt.wantArgs) {
+				t.Errorf("CommandLine.Args() = %v, want %v", got.Args(), tt.wantArgs)
+			}
+			if _, ok := got.Value(verbose); !ok && tt.args[0] == "-v" {
+				t.Errorf("CommandLine.Value() persistent flag not parsed")
+			}
+			if val, _ := got.Value(name); val != "x" {
+				t.Errorf("CommandLine.Value() = %v, want %v", val, "x")
+			}
+		})
+	}
+}
+
+func TestParser_ParseCommand_suggest(t *testing.T) {
+	root := NewCommand("tool", "", nil)
+	root.AddCommand(NewCommand("remote", "", nil))
+
+	_, err := NewParser().ParseCommand(root, []string{"remot"})
+	want := `unknown command "remot" for "tool", did you mean "remote"?`
+	if err == nil || err.Error() != want {
+		t.Errorf("Parser.ParseCommand() error = %v, want %v", err, want)
+	}
+}
+
+func TestParser_ParseCommand_invalidFlags(t *testing.T) {
+	root := NewCommand("tool", "", nil)
+	root.PersistentFlags.AddNewFlag('v', "verbose", "", false)
+	run := NewCommand("run", "", func(CommandLine) error { return nil })
+	run.Flags.AddNewFlag('v', "version", "", false)
+	root.AddCommand(run)
+
+	_, err := NewParser().ParseCommand(root, []string{"run"})
+	var ferr *InvalidFlagDefinitionError
+	if !errors.As(err, &ferr) || ferr.Command != "tool run" {
+		t.Fatalf("Parser.ParseCommand() error = %v, want *InvalidFlagDefinitionError in tool run", err)
+	}
+	if want := ` in command "tool run"`; !strings.HasSuffix(err.Error(), want) {
+		t.Errorf("Parser.ParseCommand() error = %v, want suffix %v", err, want)
+	}
+}
+
+func TestParser_ParseCommand_required(t *testing.T) {
+	root := NewCommand("tool", "", nil)
+	root.Flags.AddNewRequiredFlag(0, "token", "", true)
+	root.PersistentFlags.AddNewRequiredFlag(0, "user", "", true)
+	sub := NewCommand("sub", "", func(CommandLine) error { return nil })
+	root.AddCommand(sub)
+
+	if _, err := NewParser().ParseCommand(root, []string{"--user=a", "sub"}); err != nil {
+		t.Errorf("Parser.ParseCommand() error = %v, want nil", err)
+	}
+
+	_, err := NewParser().ParseCommand(root, []string{"sub"})
+	if e, ok := err.(*MissingRequiredError); !ok || len(e.Flags) != 1 || e.Flags[0].Long != "user" {
+		t.Errorf("Parser.ParseCommand() error = %v, want missing --user", err)
+	}
+}
+
+func TestParser_ParseCommand_naming(t *testing.T) {
+	root := NewCommand("tool", "", nil)
+	root.PersistentFlags.Naming = &NamingPolicy{CaseSensitive: true, MinLength: 2}
+	fv, _ := root.PersistentFlags.AddNewFlag(0, "Verbose", "", false)
+	sub := NewCommand("sub", "", func(CommandLine) error { return nil })
+	sub.Flags.AddNewFlag(0, "Name", "", true)
+	root.AddCommand(sub)
+
+	_, err := NewParser().ParseCommand(root, []string{"sub", "--name=x"})
+	var ferr *InvalidFlagDefinitionError
+	if !errors.As(err, &ferr) || ferr.Flag != fv || ferr.Command != "tool sub" {
+		t.Errorf("Parser.ParseCommand() error = %v, want *InvalidFlagDefinitionError for --Verbose", err)
+	}
+	if fv.Long != "Verbose" {
+		t.Errorf("Flag.Long = %v, want Verbose", fv.Long)
+	}
+
+	// Commands without flags of their own inherit the policy.
+	other := NewCommand("other", "", func(CommandLine) error { return nil })
+	root.AddCommand(other)
+	if _, err := NewParser().ParseCommand(root, []string{"other", "--Verbose"}); err != nil {
+		t.Errorf("Parser.ParseCommand() error = %v, want nil", err)
+	}
+}
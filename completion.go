@@ -0,0 +1,335 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// CompleteCommand is the hidden command answered by Parser.Complete.
+const CompleteCommand = "__complete"
+
+// CompletionKind represents how the values of a flag or positional argument are completed.
+type CompletionKind int
+
+const (
+	CompleteDefault CompletionKind = iota // the default shell completion
+	CompleteNothing                       // no completion
+	CompleteFiles                         // file names
+	CompleteDirs                          // directory names
+	CompleteChoices                       // the fixed Completion choices
+)
+
+// Completion represents a hint for completing the values of a flag or positional argument.
+type Completion struct {
+	Kind    CompletionKind // how values are completed
+	Choices []string       // the values to complete for CompleteChoices
+}
+
+// Shell represents a shell completion scripts are generated for.
+type Shell string
+
+const (
+	Bash Shell = "bash"
+	Zsh  Shell = "zsh"
+	Fish Shell = "fish"
+)
+
+// Directives written as the last line of a Parser.Complete answer.
+var completeDirectives = [...]string{
+	CompleteDefault: ":default",
+	CompleteNothing: ":nothing",
+	CompleteFiles:   ":files",
+	CompleteDirs:    ":dirs",
+	CompleteChoices: ":nothing",
+}
+
+// GenerateCompletion writes a completion script for the program to the Writer.
+// The script completes by running "program __complete <words>", which the program
+// answers with Parser.Complete, so completions stay in sync with the binary.
+// Returns an error if the shell is not supported.
+func GenerateCompletion(w io.Writer, shell Shell, program string) error {
+	var script string
+
+	switch shell {
+	case Bash:
+		script = bashCompletion
+	case Zsh:
+		script = zshCompletion
+	case Fish:
+		script = fishCompletion
+	default:
+		return fmt.Errorf(`cli.GenerateCompletion: unsupported shell "%v"`, shell)
+	}
+
+	r := strings.NewReplacer("{{program}}", program, "{{ident}}", shellIdent(program), "{{command}}", CompleteCommand)
+	_, err := io.WriteString(w, r.Replace(script))
+	return err
+}
+
+// Complete answers the hidden CompleteCommand if it is the first argument, and reports whether it was,
+// it should be called before parsing the arguments.
+// The remaining arguments are the words on the command line, the last being the word to complete.
+// Each candidate is written on its own line followed by a tab and its description,
+// the last line is a directive for the shell: ":default", ":nothing", ":files" or ":dirs".
+// Returns an error if writing to the Writer fails.
+func (p *Parser) Complete(w io.Writer, flags *FlagSet, args []string) (bool, error) {
+	if len(args) == 0 || args[0] != CompleteCommand {
+		return false, nil
+	}
+
+	words := args[1:]
+	cur := ""
+	if len(words) > 0 {
+		cur = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	buf := new(bytes.Buffer)
+	kind := p.complete(buf, flags, words, cur)
+	buf.WriteString(completeDirectives[kind])
+	buf.WriteByte('\n')
+
+	_, err := w.Write(buf.Bytes())
+	return true, err
+}
+
+// complete writes the candidates for the word cur following the words, and returns the shell directive.
+func (p *Parser) complete(buf *bytes.Buffer, flags *FlagSet, words []string, cur string) CompletionKind {
+	// The value of the previous flag, ie. "--output <cur>".
+	if n := len(words); n > 0 && !strings.Contains(words[n-1], string(ValueSeparator)) {
+		if flag := completeFlag(flags, words[n-1]); flag != nil && flag.HasArg && !flag.OptionalArg {
+			return completeValues(buf, flagCompletion(flag), "", cur)
+		}
+	}
+
+	// The attached value of a long flag, ie. "--output=<cur>".
+	if strings.HasPrefix(cur, LongPrefix) {
+		if i := strings.IndexRune(cur, ValueSeparator); i > -1 {
+			if flag, ok := flags.long(cur[len(LongPrefix):i]); ok {
+				return completeValues(buf, flagCompletion(flag), cur[:i+1], cur[i+1:])
+			}
+			return CompleteNothing
+		}
+	}
+
+	if strings.HasPrefix(cur, ShortPrefix) {
+		for _, flag := range flags.Flags() {
+			for _, word := range flagWords(flag) {
+				if strings.HasPrefix(word, cur) {
+					writeCandidate(buf, word, flag.Description)
+				}
+			}
+		}
+		return CompleteNothing
+	}
+
+	// Positional arguments, counting the preceding words that are not flags or flag values.
+	args := flags.Args()
+	if len(args) == 0 {
+		return CompleteDefault
+	}
+
+	n := 0
+	for i, word := range words {
+		if word == LongPrefix {
+			n += len(words) - i - 1
+			break
+		}
+		if strings.HasPrefix(word, ShortPrefix) && word != ShortPrefix {
+			continue
+		}
+		if i > 0 && !strings.Contains(words[i-1], string(ValueSeparator)) {
+			if flag := completeFlag(flags, words[i-1]); flag != nil && flag.HasArg && !flag.OptionalArg {
+				continue
+			}
+		}
+		n++
+	}
+
+	var arg *Arg
+	if n < len(args) {
+		arg = args[n]
+	} else if last := args[len(args)-1]; last.Variadic {
+		arg = last
+	}
+	if arg == nil {
+		return CompleteNothing
+	}
+	return completeValues(buf, arg.Completion, "", cur)
+}
+
+// completeFlag returns the Flag for a flag word, the last short flag for concatenated short flags.
+func completeFlag(flags *FlagSet, word string) *Flag {
+	if strings.HasPrefix(word, LongPrefix) {
+		flag, _ := flags.long(word[len(LongPrefix):])
+		return flag
+	}
+	if strings.HasPrefix(word, ShortPrefix) && len(word) > len(ShortPrefix) {
+		runes := []rune(word[len(ShortPrefix):])
+		return flags.shorts[runes[len(runes)-1]]
+	}
+	return nil
+}
+
+// flagCompletion returns the Completion for the argument of the Flag, its choices if not set.
+func flagCompletion(flag *Flag) Completion {
+	if flag.Completion.Kind == CompleteDefault && len(flag.Choices) > 0 {
+		return Completion{Kind: CompleteChoices, Choices: flag.Choices}
+	}
+	return flag.Completion
+}
+
+// completeValues writes the choices starting with cur, prefixed with the prefix.
+func completeValues(buf *bytes.Buffer, c Completion, prefix string, cur string) CompletionKind {
+	if c.Kind != CompleteChoices {
+		return c.Kind
+	}
+	for _, choice := range c.Choices {
+		if strings.HasPrefix(choice, cur) {
+			writeCandidate(buf, prefix+choice, "")
+		}
+	}
+	return CompleteChoices
+}
+
+func writeCandidate(buf *bytes.Buffer, candidate string, desc string) {
+	buf.WriteString(candidate)
+	if len(desc) > 0 {
+		buf.WriteByte('\t')
+		buf.WriteString(desc)
+	}
+	buf.WriteByte('\n')
+}
+
+// shellIdent returns the program name as a shell function identifier.
+func shellIdent(program string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, program)
+}
+
+// flagWords returns the short and long flag words for the Flag, ie. "-o", "--output".
+func flagWords(flag *Flag) []string {
+	var words []string
+	if flag.Short != 0 {
+		words = append(words, ShortPrefix+string(flag.Short))
+	}
+	if len(flag.Long) > 0 {
+		words = append(words, LongPrefix+flag.Long)
+	}
+	if flag.Negatable {
+		words = append(words, LongPrefix+NegationPrefix+flag.Long)
+	}
+	return words
+}
+
+// Completion scripts, {{program}} is the program name and {{ident}} the program name as an identifier.
+
+const bashCompletion = `# bash completion for {{program}}
+_{{ident}}_complete() {
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words
+    read -ra words <<< "$line"
+    [[ "$line" == *[[:space:]] || ${#words[@]} -lt 2 ]] && words+=("")
+
+    local cur="${words[-1]}"
+    local -a lines
+    mapfile -t lines < <("${words[0]}" {{command}} "${words[@]:1}" 2>/dev/null)
+    (( ${#lines[@]} > 0 )) || return
+
+    local directive="${lines[-1]}"
+    unset 'lines[-1]'
+
+    # Bash completes the part after '=', ie. "j" for "--format=j".
+    local prefix=""
+    [[ "$COMP_WORDBREAKS" == *=* && "$cur" == *=* ]] && prefix="${cur%%=*}="
+
+    COMPREPLY=()
+    case "$directive" in
+        :files|:default)
+            compopt -o filenames
+            mapfile -t COMPREPLY < <(compgen -f -- "${cur#"$prefix"}")
+            ;;
+        :dirs)
+            compopt -o filenames
+            mapfile -t COMPREPLY < <(compgen -d -- "${cur#"$prefix"}")
+            ;;
+        *)
+            local candidate
+            for candidate in "${lines[@]}"; do
+                candidate="${candidate%%$'\t'*}"
+                COMPREPLY+=("${candidate#"$prefix"}")
+            done
+            ;;
+    esac
+}
+complete -F _{{ident}}_complete {{program}}
+`
+
+const zshCompletion = `#compdef {{program}}
+compdef _{{ident}} {{program}}
+
+_{{ident}}() {
+    local -a lines candidates
+    local directive line value desc
+
+    lines=("${(@f)$("${(Q)words[1]}" {{command}} "${(@Q)words[2,CURRENT]}" 2>/dev/null)}")
+    directive="${lines[-1]}"
+    lines=("${(@)lines[1,-2]}")
+
+    case "$directive" in
+        :files|:default)
+            _files
+            ;;
+        :dirs)
+            _files -/
+            ;;
+        *)
+            for line in "${lines[@]}"; do
+                value="${line%%$'\t'*}"
+                desc=""
+                [[ "$line" == *$'\t'* ]] && desc="${line#*$'\t'}"
+                value="${value//:/\\:}"
+                if [[ -n "$desc" ]]; then
+                    candidates+=("$value:$desc")
+                else
+                    candidates+=("$value")
+                fi
+            done
+            (( ${#candidates} )) && _describe -t values 'values' candidates
+            ;;
+    esac
+}
+
+if [[ "$funcstack[1]" == "_{{ident}}" ]]; then
+    _{{ident}} "$@"
+fi
+`
+
+const fishCompletion = `# fish completion for {{program}}
+function __{{ident}}_complete
+    set -l tokens (commandline -opc)
+    set -l cur (commandline -ct)
+    set -l lines ($tokens[1] {{command}} $tokens[2..-1] "$cur" 2>/dev/null)
+    test (count $lines) -gt 0; or return
+
+    set -l directive $lines[-1]
+    set -e lines[-1]
+    switch $directive
+        case :files :default
+            __fish_complete_path "$cur"
+        case :dirs
+            __fish_complete_directories "$cur"
+        case '*'
+            printf '%s\n' $lines
+    end
+end
+
+complete -c {{program}} -f -a '(__{{ident}}_complete)'
+`
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newCompletionFlagSet() *FlagSet {
+	fs := NewFlagSet()
+	fs.AddNewFlag('a', "all", "show all", false)
+	fo, _ := fs.AddNewFlag('o', "output", "the output file", true)
+	fo.Completion = Completion{Kind: CompleteFiles}
+	ff, _ := fs.AddNewFlag(0, "format", "the format", true)
+	ff.Completion = Completion{Kind: CompleteChoices, Choices: []string{"json", "text"}}
+	arg, _ := fs.AddNewArg("DIR", "the directory", true)
+	arg.Completion = Completion{Kind: CompleteDirs}
+	return fs
+}
+
+func TestParser_Complete(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"flags", []string{"__complete", "--f"}, "--format\tthe format\n:nothing\n"},
+		{"short and long", []string{"__complete", "-"}, "-a\tshow all\n--all\tshow all\n-o\tthe output file\n--output\tthe output file\n--format\tthe format\n:nothing\n"},
+		{"separate value", []string{"__complete", "--format", "j"}, "json\n:nothing\n"},
+		{"attached value", []string{"__complete", "--format="}, "--format=json\n--format=text\n:nothing\n"},
+		{"short value", []string{"__complete", "-ao", ""}, ":files\n"},
+		{"positional", []string{"__complete", "-o", "out", ""}, ":dirs\n"},
+		{"too many", []string{"__complete", "dir", ""}, ":nothing\n"},
+		{"none", []string{"__complete"}, ":dirs\n"},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		ok, err := NewParser().Complete(buf, newCompletionFlagSet(), tt.args)
+		if !ok || err != nil {
+			t.Errorf("%q. Parser.Complete() = %v, %v, want true, nil", tt.name, ok, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%q. Parser.Complete() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if ok, _ := NewParser().Complete(new(bytes.Buffer), NewFlagSet(), []string{"--all"}); ok {
+		t.Errorf("Parser.Complete() = true, want false")
+	}
+	if _, err := NewParser().Complete(failingWriter{}, NewFlagSet(), []string{"__complete"}); err == nil {
+		t.Errorf("Parser.Complete() error = nil, want error")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestGenerateCompletion(t *testing.T) {
+	tests := []struct {
+		shell Shell
+		want  []string
+	}{
+		{Bash, []string{
+			"_my_tool_complete() {",
+			`"${words[0]}" __complete "${words[@]:1}"`,
+			"complete -F _my_tool_complete my-tool",
+		}},
+		{Zsh, []string{
+			"#compdef my-tool",
+			`"${(Q)words[1]}" __complete "${(@Q)words[2,CURRENT]}"`,
+			"compdef _my_tool my-tool",
+		}},
+		{Fish, []string{
+			"function __my_tool_complete",
+			`$tokens[1] __complete $tokens[2..-1] "$cur"`,
+			"complete -c my-tool -f -a '(__my_tool_complete)'",
+		}},
+	}
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		if err := GenerateCompletion(buf, tt.shell, "my-tool"); err != nil {
+			t.Errorf("GenerateCompletion(%v) error = %v", tt.shell, err)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("GenerateCompletion(%v) = %q, want to contain %q", tt.shell, buf.String(), want)
+			}
+		}
+	}
+
+	if err := GenerateCompletion(new(bytes.Buffer), "csh", "tool"); err == nil {
+		t.Errorf("GenerateCompletion(csh) error = nil, want error")
+	}
+}
+
+func TestParser_Complete_choices(t *testing.T) {
+	fs := NewFlagSet()
+	fs.AddNewChoiceFlag(0, "color", "", "auto", "always", "never")
+
+	buf := new(bytes.Buffer)
+	NewParser().Complete(buf, fs, []string{"__complete", "--color", "a"})
+	if got, want := buf.String(), "auto\nalways\n:nothing\n"; got != want {
+		t.Errorf("Parser.Complete() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,353 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConfigFormat represents the format of a config file.
+type ConfigFormat int
+
+const (
+	FormatJSON ConfigFormat = iota // a JSON object
+	FormatTOML                     // a TOML subset, without tables or multi-line values
+	FormatINI                      // INI key/value pairs, sections are ignored
+)
+
+// Config represents flag values loaded from a config file.
+// Values from a Config have a lower precedence than the arguments and environment variables.
+type Config struct {
+	values map[*Flag][]string
+}
+
+// configEntry represents a key and its values in a config file.
+type configEntry struct {
+	key    string
+	values []string
+	line   int // the line of the key (0 if unknown)
+}
+
+// LoadConfig reads the config file at path, keyed by the long flags in the FlagSet.
+// The format is chosen by the file extension: .json, .toml, or .ini/.cfg/.conf.
+func LoadConfig(path string, flags *FlagSet) (*Config, error) {
+	var format ConfigFormat
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = FormatJSON
+	case ".toml":
+		format = FormatTOML
+	case ".ini", ".cfg", ".conf":
+		format = FormatINI
+	default:
+		return nil, &ConfigError{File: path, Err: fmt.Errorf(`unknown config format "%v"`, filepath.Ext(path))}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cfg, err := ParseConfig(file, format, flags)
+	if err != nil {
+		if e, ok := err.(*ConfigError); ok {
+			e.File = path
+			return nil, e
+		}
+		return nil, &ConfigError{File: path, Err: err}
+	}
+	return cfg, nil
+}
+
+// ParseConfig reads a config in the specified format, keyed by the long flags in the FlagSet.
+// Returns a *ConfigError if a key is not a long flag, or a flag that is not repeatable has multiple values.
+func ParseConfig(r io.Reader, format ConfigFormat, flags *FlagSet) (*Config, error) {
+	var entries []configEntry
+	var err error
+
+	switch format {
+	case FormatJSON:
+		entries, err = parseJSONConfig(r)
+	case FormatTOML:
+		entries, err = parseTOMLConfig(r)
+	case FormatINI:
+		entries, err = parseINIConfig(r)
+	default:
+		return nil, &ConfigError{Err: fmt.Errorf("unknown config format %d", format)}
+	}
+	if err != nil {
+		if _, ok := err.(*ConfigError); ok {
+			return nil, err
+		}
+		return nil, &ConfigError{Err: err}
+	}
+
+	cfg := &Config{values: make(map[*Flag][]string)}
+	for _, e := range entries {
+		flag, ok := flags.long(e.key)
+		if !ok {
+			return nil, configError(e.line, unknownKeyError(e.key, flags))
+		}
+
+		vals := append(cfg.values[flag], e.values...)
+		if len(vals) > 1 && !flag.Repeatable {
+			return nil, configError(e.line, fmt.Errorf(`key "%v" has multiple values but %v is not repeatable`, e.key, flag))
+		}
+		cfg.values[flag] = vals
+	}
+
+	return cfg, nil
+}
+
+// Values returns the values loaded for the specified Flag.
+func (c *Config) Values(flag *Flag) ([]string, bool) {
+	vals, ok := c.values[flag]
+	return vals, ok
+}
+
+// NewConfigFlag constructs a new --config flag, for use as Parser.ConfigFlag.
+func NewConfigFlag() *Flag {
+	flag := NewFlag(0, "config", "read flags from a config file", true)
+	flag.ArgName = "FILE"
+	return flag
+}
+
+// unknownKeyError returns an error for an unknown key,
+// suggesting the long flags sharing the longest prefix with the key.
+func unknownKeyError(key string, flags *FlagSet) error {
+	for prefix := []rune(key); len(prefix) > 1; prefix = prefix[:len(prefix)-1] {
+		if m := flags.longMatches(string(prefix)); len(m) > 0 {
+			return &UnknownKeyError{Key: key, Suggestions: m}
+		}
+	}
+	return &UnknownKeyError{Key: key}
+}
+
+func configError(line int, err error) error {
+	return &ConfigError{Line: line, Err: err}
+}
+
+func parseJSONConfig(r io.Reader) ([]configEntry, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+
+	entries := make([]configEntry, 0, len(obj))
+	for key, v := range obj {
+		e := configEntry{key: key}
+
+		if arr, ok := v.([]interface{}); ok {
+			for _, v := range arr {
+				s, err := jsonScalar(key, v)
+				if err != nil {
+					return nil, err
+				}
+				e.values = append(e.values, s)
+			}
+		} else {
+			s, err := jsonScalar(key, v)
+			if err != nil {
+				return nil, err
+			}
+			e.values = []string{s}
+		}
+
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func jsonScalar(key string, v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf(`key "%v" has unsupported value %v`, key, v)
+}
+
+func parseTOMLConfig(r io.Reader) ([]configEntry, error) {
+	var entries []configEntry
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(s.Text(), "#"))
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '[' {
+			return nil, configError(n, errors.New("tables are not supported"))
+		}
+
+		i := strings.IndexByte(line, '=')
+		if i == -1 {
+			return nil, configError(n, errors.New("expected key = value"))
+		}
+		key, raw := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if k, err := tomlString(key); err == nil {
+			key = k
+		}
+
+		vals, err := tomlValue(raw)
+		if err != nil {
+			return nil, configError(n, fmt.Errorf(`key "%v": %v`, key, err))
+		}
+		entries = append(entries, configEntry{key, vals, n})
+	}
+
+	return entries, s.Err()
+}
+
+// tomlValue parses a single line TOML string, number, boolean or array of these.
+func tomlValue(raw string) ([]string, error) {
+	if !strings.HasPrefix(raw, "[") {
+		v, err := tomlScalar(raw)
+		if err != nil {
+			return nil, err
+		}
+		return []string{v}, nil
+	}
+	if !strings.HasSuffix(raw, "]") {
+		return nil, errors.New("unterminated array")
+	}
+
+	var vals []string
+	for _, item := range splitUnquoted(raw[1:len(raw)-1], ',') {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			// Allow a trailing comma.
+			continue
+		}
+		v, err := tomlScalar(item)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+func tomlScalar(raw string) (string, error) {
+	if v, err := tomlString(raw); err == nil {
+		return v, nil
+	} else if len(raw) > 0 && (raw[0] == '"' || raw[0] == '\'') {
+		return "", err
+	}
+
+	switch raw {
+	case "true", "false":
+		return raw, nil
+	}
+	if _, err := strconv.ParseFloat(strings.Replace(raw, "_", "", -1), 64); err == nil {
+		return strings.Replace(raw, "_", "", -1), nil
+	}
+	return "", fmt.Errorf("invalid value %v", raw)
+}
+
+// tomlString parses a basic "string" or literal 'string'.
+func tomlString(raw string) (string, error) {
+	if len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'' {
+		return raw[1 : len(raw)-1], nil
+	}
+	if len(raw) >= 2 && raw[0] == '"' {
+		return strconv.Unquote(raw)
+	}
+	return "", errors.New("not a string")
+}
+
+func parseINIConfig(r io.Reader) ([]configEntry, error) {
+	var entries []configEntry
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if len(line) == 0 || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, configError(n, errors.New("unterminated section"))
+			}
+			continue
+		}
+
+		i := strings.IndexAny(line, "=:")
+		if i == -1 {
+			return nil, configError(n, errors.New("expected key = value"))
+		}
+		key, val := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+
+		// Strip optional quotes.
+		if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+			val = val[1 : len(val)-1]
+		}
+		entries = append(entries, configEntry{key, []string{val}, n})
+	}
+
+	return entries, s.Err()
+}
+
+// stripComment removes a trailing comment starting with the prefix outside of quotes.
+func stripComment(line string, prefix string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(line[i:], prefix):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitUnquoted splits s at each sep outside of quotes.
+func splitUnquoted(s string, sep byte) []string {
+	var parts []string
+	buf := new(bytes.Buffer)
+
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' && i+1 < len(s) {
+				buf.WriteByte(c)
+				i++
+				c = s[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			parts = append(parts, buf.String())
+			buf.Reset()
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return append(parts, buf.String())
+}
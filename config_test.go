@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newConfigFlagSet() (*FlagSet, *Flag, *Flag, *Flag) {
+	fs := NewFlagSet()
+	fp, _ := fs.AddNewFlag('p', "port", "", true)
+	fp.Type = TypeInt
+	ft, _ := fs.AddNewFlag(0, "tag", "", true)
+	ft.Repeatable = true
+	fv, _ := fs.AddNewFlag('v', "verbose", "", false)
+	return fs, fp, ft, fv
+}
+
+func TestParseConfig(t *testing.T) {
+	fs, fp, ft, fv := newConfigFlagSet()
+
+	tests := []struct {
+		name    string
+		format  ConfigFormat
+		input   string
+		want    map[*Flag][]string
+		wantErr string
+	}{
+		{
+			"json",
+			FormatJSON,
+			`{"port": 8080, "tag": ["a", "b"], "verbose": true}`,
+			map[*Flag][]string{fp: {"8080"}, ft: {"a", "b"}, fv: {"true"}},
+			"",
+		},
+		{
+			"json nested object",
+			FormatJSON,
+			`{"port": {"value": 1}}`,
+			nil,
+			`key "port" has unsupported value`,
+		},
+		{
+			"toml",
+			FormatTOML,
+			"# comment\nport = 8_080 # trailing\ntag = [\"a#b\", 'c',]\n\"verbose\" = false\n",
+			map[*Flag][]string{fp: {"8080"}, ft: {"a#b", "c"}, fv: {"false"}},
+			"",
+		},
+		{
+			"toml table",
+			FormatTOML,
+			"port = 1\n[server]\n",
+			nil,
+			"line 2: tables are not supported",
+		},
+		{
+			"toml invalid value",
+			FormatTOML,
+			"port = eighty",
+			nil,
+			`line 1: key "port": invalid value eighty`,
+		},
+		{
+			"ini",
+			FormatINI,
+			"; comment\n[main]\nport = 8080\ntag: a\ntag = \"b c\"\n",
+			map[*Flag][]string{fp: {"8080"}, ft: {"a", "b c"}},
+			"",
+		},
+		{
+			"ini missing separator",
+			FormatINI,
+			"port",
+			nil,
+			"line 1: expected key = value",
+		},
+		{
+			"unknown key",
+			FormatINI,
+			"\nverbsoe = true",
+			nil,
+			`line 2: unknown key "verbsoe", did you mean "verbose"?`,
+		},
+		{
+			"not repeatable",
+			FormatINI,
+			"port = 1\nport = 2",
+			nil,
+			"line 2: key \"port\" has multiple values",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConfig(strings.NewReader(tt.input), tt.format, fs)
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("ParseConfig() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.values, tt.want) {
+				t.Errorf("ParseConfig() = %v, want %v", got.values, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseArgs_config(t *testing.T) {
+	fs, fp, ft, fv := newConfigFlagSet()
+	fh, _ := fs.AddNewFlag(0, "host", "", true)
+	fh.EnvVar = "CLI_TEST_CONFIG_HOST"
+	fh.Default = "localhost"
+	fc := NewConfigFlag()
+	fs.AddFlag(fc)
+
+	dir, err := ioutil.TempDir("", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.toml")
+	data := "port = 8080\ntag = [\"a\", \"b\"]\nverbose = true\nhost = \"config.example.com\"\n"
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("CLI_TEST_CONFIG_HOST", "env.example.com")
+	defer os.Unsetenv("CLI_TEST_CONFIG_HOST")
+
+	p := NewParser()
+	p.ConfigFlag = fc
+	got, err := p.ParseArgs(fs, []string{"--config", path, "-p", "9090"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+
+	if n, _ := got.Int(fp); n != 9090 || got.Source(fp) != SourceArgs {
+		t.Errorf("CommandLine.Int() = %v from %v, want 9090 from args", n, got.Source(fp))
+	}
+	if val, _ := got.Value(fh); val != "env.example.com" || got.Source(fh) != SourceEnv {
+		t.Errorf("CommandLine.Value() = %v from %v, want env.example.com from env", val, got.Source(fh))
+	}
+	if vals := got.Values(ft); !reflect.DeepEqual(vals, []string{"a", "b"}) || got.Source(ft) != SourceConfig {
+		t.Errorf("CommandLine.Values() = %v from %v, want [a b] from config", vals, got.Source(ft))
+	}
+	if _, ok := got.Value(fv); !ok || got.Source(fv) != SourceConfig {
+		t.Errorf("CommandLine.Value() ok = %v from %v, want true from config", ok, got.Source(fv))
+	}
+
+	if _, err := p.ParseArgs(fs, []string{"--config", filepath.Join(dir, "none.toml")}); err == nil {
+		t.Errorf("Parser.ParseArgs() error = %v, wantErr true for missing config", err)
+	}
+
+	p.ConfigFlag = nil
+	p.Config, _ = ParseConfig(strings.NewReader(`{"port": 1}`), FormatJSON, fs)
+	got, err = p.ParseArgs(fs, nil)
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	if n, _ := got.Int(fp); n != 1 {
+		t.Errorf("CommandLine.Int() = %v, want 1", n)
+	}
+}
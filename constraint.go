@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConstraintKind represents the relationship a Constraint enforces between flags.
+type ConstraintKind int
+
+const (
+	MutuallyExclusive ConstraintKind = iota // at most one of the flags is set
+	AtLeastOneOf                            // one or more of the flags is set
+	ExactlyOneOf                            // exactly one of the flags is set
+	Requires                                // if the flag is set, all the flags are set
+	Conflicts                               // if the flag is set, none of the flags are set
+)
+
+// Constraint represents a relationship between flags in a FlagSet, checked after parsing.
+// Flags set by an argument, environment variable or config file count as set, defaults do not.
+type Constraint struct {
+	Kind  ConstraintKind
+	Flag  *Flag   // the flag the constraint applies to, for Requires and Conflicts (nil otherwise)
+	Flags []*Flag // the related flags
+}
+
+// String returns a description of the Constraint, ie. "--json, --yaml are mutually exclusive".
+func (c *Constraint) String() string {
+	names := flagNames(c.Flags)
+
+	switch c.Kind {
+	case MutuallyExclusive:
+		return fmt.Sprintf("%v are mutually exclusive", names)
+	case AtLeastOneOf:
+		return fmt.Sprintf("at least one of %v is required", names)
+	case ExactlyOneOf:
+		return fmt.Sprintf("exactly one of %v is required", names)
+	case Requires:
+		return fmt.Sprintf("%v requires %v", displayName(c.Flag), names)
+	case Conflicts:
+		return fmt.Sprintf("%v conflicts with %v", displayName(c.Flag), names)
+	}
+	return ""
+}
+
+// check returns the flags violating the Constraint and whether they are missing rather than conflicting.
+// Flags set to false, ie. --no-json, are not enabled: they neither satisfy nor trigger a Constraint.
+// Returns nil if the Constraint is satisfied.
+func (c *Constraint) check(isEnabled func(*Flag) bool) ([]*Flag, bool) {
+	var enabled, disabled []*Flag
+	for _, flag := range c.Flags {
+		if isEnabled(flag) {
+			enabled = append(enabled, flag)
+		} else {
+			disabled = append(disabled, flag)
+		}
+	}
+
+	switch c.Kind {
+	case MutuallyExclusive:
+		if len(enabled) > 1 {
+			return enabled, false
+		}
+	case AtLeastOneOf:
+		if len(enabled) == 0 {
+			return disabled, true
+		}
+	case ExactlyOneOf:
+		if len(enabled) == 0 {
+			return disabled, true
+		}
+		if len(enabled) > 1 {
+			return enabled, false
+		}
+	case Requires:
+		if isEnabled(c.Flag) && len(disabled) > 0 {
+			return disabled, true
+		}
+	case Conflicts:
+		if isEnabled(c.Flag) && len(enabled) > 0 {
+			return enabled, false
+		}
+	}
+	return nil, false
+}
+
+// MutuallyExclusive adds a constraint that at most one of the flags is set.
+// Returns an error if fewer than two flags are specified or a flag is not in the FlagSet.
+func (f *FlagSet) MutuallyExclusive(flags ...*Flag) error {
+	return f.addConstraint(&Constraint{Kind: MutuallyExclusive, Flags: flags}, 2)
+}
+
+// AtLeastOneOf adds a constraint that one or more of the flags is set.
+// Returns an error if no flags are specified or a flag is not in the FlagSet.
+func (f *FlagSet) AtLeastOneOf(flags ...*Flag) error {
+	return f.addConstraint(&Constraint{Kind: AtLeastOneOf, Flags: flags}, 1)
+}
+
+// ExactlyOneOf adds a constraint that exactly one of the flags is set.
+// Returns an error if no flags are specified or a flag is not in the FlagSet.
+func (f *FlagSet) ExactlyOneOf(flags ...*Flag) error {
+	return f.addConstraint(&Constraint{Kind: ExactlyOneOf, Flags: flags}, 1)
+}
+
+// Requires adds a constraint that if the flag is set, all the required flags are set.
+// Returns an error if no required flags are specified or a flag is not in the FlagSet.
+func (f *FlagSet) Requires(flag *Flag, required ...*Flag) error {
+	return f.addConstraint(&Constraint{Kind: Requires, Flag: flag, Flags: required}, 1)
+}
+
+// Conflicts adds a constraint that if the flag is set, none of the conflicting flags are set.
+// Returns an error if no conflicting flags are specified or a flag is not in the FlagSet.
+func (f *FlagSet) Conflicts(flag *Flag, conflicts ...*Flag) error {
+	return f.addConstraint(&Constraint{Kind: Conflicts, Flag: flag, Flags: conflicts}, 1)
+}
+
+// Constraints returns a slice with the constraints in this FlagSet, in the order added.
+func (f *FlagSet) Constraints() []*Constraint {
+	constraints := make([]*Constraint, len(f.constraints))
+	copy(constraints, f.constraints)
+	return constraints
+}
+
+func (f *FlagSet) addConstraint(c *Constraint, min int) error {
+	if len(c.Flags) < min {
+		return &InvalidFlagDefinitionError{Flag: c.Flag, Reason: fmt.Sprintf("constraint needs %d or more flags", min)}
+	}
+
+	flags := c.Flags
+	if c.Flag != nil {
+		flags = append([]*Flag{c.Flag}, flags...)
+	}
+	for _, flag := range flags {
+		if !f.contains(flag) {
+			return &InvalidFlagDefinitionError{Flag: flag, Reason: fmt.Sprintf("constrained flag %v is not in the flag set", displayName(flag))}
+		}
+	}
+
+	f.constraints = append(f.constraints, c)
+	return nil
+}
+
+// contains returns whether the Flag was added to this FlagSet.
+func (f *FlagSet) contains(flag *Flag) bool {
+	if flag == nil {
+		return false
+	}
+	if flag.Short != 0 && f.shorts[flag.Short] == flag {
+		return true
+	}
+	return len(flag.Long) > 0 && f.longs[flag.Long] == flag
+}
+
+// displayName returns the name a Flag is referred to by in messages, ie. "--verbose" or "-v".
+func displayName(flag *Flag) string {
+	if len(flag.Long) > 0 {
+		return LongPrefix + flag.Long
+	}
+	return ShortPrefix + string(flag.Short)
+}
+
+// flagNames returns the display names of the flags, separated by commas.
+func flagNames(flags []*Flag) string {
+	names := make([]string, len(flags))
+	for i, flag := range flags {
+		names[i] = displayName(flag)
+	}
+	return strings.Join(names, commaSeparator)
+}
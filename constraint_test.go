@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParser_ParseArgs_constraints(t *testing.T) {
+	tests := []struct {
+		name    string
+		add     func(fs *FlagSet, json, yaml, key, cert *Flag) error
+		args    []string
+		wantErr string
+	}{
+		{"exclusive ok", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			return fs.MutuallyExclusive(json, yaml)
+		}, []string{"--json"}, ""},
+		{"exclusive", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			return fs.MutuallyExclusive(json, yaml)
+		}, []string{"--json", "--yaml"}, "flags --json, --yaml can not be used together"},
+		{"exclusive negated", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			json.Negatable, yaml.Negatable = true, true
+			return fs.MutuallyExclusive(json, yaml)
+		}, []string{"--json", "--no-yaml"}, ""},
+		{"at least one", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			return fs.AtLeastOneOf(json, yaml)
+		}, nil, "one of flags --json, --yaml is required"},
+		{"at least one negated", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			json.Negatable, yaml.Negatable = true, true
+			return fs.AtLeastOneOf(json, yaml)
+		}, []string{"--no-json"}, "one of flags --json, --yaml is required"},
+		{"exactly one none", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			return fs.ExactlyOneOf(json, yaml)
+		}, nil, "one of flags --json, --yaml is required"},
+		{"exactly one both", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			return fs.ExactlyOneOf(json, yaml)
+		}, []string{"--yaml", "--json"}, "flags --json, --yaml can not be used together"},
+		{"exactly one negated", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			json.Negatable, yaml.Negatable = true, true
+			return fs.ExactlyOneOf(json, yaml)
+		}, []string{"--no-json", "--yaml"}, ""},
+		{"exactly one all negated", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			json.Negatable, yaml.Negatable = true, true
+			return fs.ExactlyOneOf(json, yaml)
+		}, []string{"--no-json", "--no-yaml"}, "one of flags --json, --yaml is required"},
+		{"requires", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			return fs.Requires(key, cert)
+		}, []string{"--key=a"}, "flag --key requires --cert"},
+		{"requires negated", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			json.Negatable, yaml.Negatable = true, true
+			return fs.Requires(json, yaml)
+		}, []string{"--no-json"}, ""},
+		{"requires negated required", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			json.Negatable, yaml.Negatable = true, true
+			return fs.Requires(json, yaml)
+		}, []string{"--json", "--no-yaml"}, "flag --json requires --yaml"},
+		{"requires unset", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			return fs.Requires(key, cert)
+		}, nil, ""},
+		{"requires default", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			cert.Default = "cert.pem"
+			return fs.Requires(key, cert)
+		}, []string{"--key=a"}, "flag --key requires --cert"},
+		{"conflicts", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			return fs.Conflicts(json, key, yaml)
+		}, []string{"--json", "--yaml"}, "flag --json can not be used with --yaml"},
+		{"conflicts negated", func(fs *FlagSet, json, yaml, key, cert *Flag) error {
+			json.Negatable, yaml.Negatable = true, true
+			return fs.Conflicts(json, key, yaml)
+		}, []string{"--json", "--no-yaml"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFlagSet()
+			json, _ := fs.AddNewFlag(0, "json", "", false)
+			yaml, _ := fs.AddNewFlag(0, "yaml", "", false)
+			key, _ := fs.AddNewFlag(0, "key", "", true)
+			cert, _ := fs.AddNewFlag(0, "cert", "", true)
+			if err := tt.add(fs, json, yaml, key, cert); err != nil {
+				t.Fatalf("FlagSet constraint error = %v", err)
+			}
+
+			_, err := NewParser().ParseArgs(fs, tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parser.ParseArgs() error = %v, want nil", err)
+				}
+				return
+			}
+			if _, ok := err.(*ConstraintError); !ok || err.Error() != tt.wantErr {
+				t.Errorf("Parser.ParseArgs() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFlagSet_MutuallyExclusive(t *testing.T) {
+	fs := NewFlagSet()
+	fa, _ := fs.AddNewFlag('a', "", "", false)
+
+	if err := fs.MutuallyExclusive(fa); err == nil {
+		t.Errorf("FlagSet.MutuallyExclusive() error = nil, want error for one flag")
+	}
+	if err := fs.MutuallyExclusive(fa, NewFlag('b', "", "", false)); err == nil {
+		t.Errorf("FlagSet.MutuallyExclusive() error = nil, want error for flag not in set")
+	}
+}
+
+func TestFormatter_PrintConstraints(t *testing.T) {
+	fs := NewFlagSet()
+	json, _ := fs.AddNewFlag(0, "json", "", false)
+	yaml, _ := fs.AddNewFlag(0, "yaml", "", false)
+	key, _ := fs.AddNewFlag(0, "key", "", true)
+	cert, _ := fs.AddNewFlag('c', "", "", true)
+	fs.MutuallyExclusive(json, yaml)
+	fs.Requires(key, cert)
+
+	want := `
+Constraints:
+  --json, --yaml are mutually exclusive
+  --key requires -c
+`
+
+	buf := new(bytes.Buffer)
+	NewFormatter().PrintConstraints(buf, *fs)
+	if got := buf.String(); got != want {
+		t.Errorf("Formatter.PrintConstraints() = %q, want %q", got, want)
+	}
+}
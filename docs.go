@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Default man page section, general commands.
+const defaultManSection = 1
+
+// PrintMan prints a generated man page in roff format for the FlagSet to the Writer.
+// The program name is the first word of the usage string, the header is the description
+// and its first line is the summary in the NAME section.
+// Returns an error if the usage string is empty.
+func (f *Formatter) PrintMan(w io.Writer, usage string, header string, flags FlagSet, footer string) error {
+	if len(usage) == 0 {
+		return errors.New("cli.Formatter.PrintMan: usage string not provided")
+	}
+
+	program, synopsis := splitUsage(usage)
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, ".TH %v %v\n", roffEscape(strings.ToUpper(program)), defaultManSection)
+
+	buf.WriteString(".SH NAME\n")
+	buf.WriteString(roffEscape(program))
+	if summary := strings.SplitN(header, "\n", 2)[0]; len(summary) > 0 {
+		buf.WriteString(` \- `)
+		buf.WriteString(roffEscape(summary))
+	}
+	buf.WriteByte('\n')
+
+	buf.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(buf, ".B %v\n", roffEscape(program))
+	if len(synopsis) > 0 {
+		buf.WriteString(roffEscape(synopsis))
+		buf.WriteByte('\n')
+	}
+
+	if len(header) > 0 {
+		buf.WriteString(".SH DESCRIPTION\n")
+		renderRoffParagraphs(buf, header)
+	}
+
+	if args := flags.Args(); len(args) > 0 {
+		buf.WriteString(".SH ARGUMENTS\n")
+		for _, arg := range args {
+			fmt.Fprintf(buf, ".TP\n\\fI%v\\fR\n", roffEscape(arg.usage()))
+			if len(arg.Description) > 0 {
+				buf.WriteString(roffEscape(arg.Description))
+				buf.WriteByte('\n')
+			}
+		}
+	}
+
+	if len(flags.Flags()) > 0 {
+		buf.WriteString(".SH OPTIONS\n")
+		renderRoffFlags(buf, flags.GroupFlags(""))
+		for _, group := range flags.Groups() {
+			fmt.Fprintf(buf, ".SS %v\n", roffEscape(group))
+			renderRoffFlags(buf, flags.GroupFlags(group))
+		}
+	}
+
+	var envs []*Flag
+	for _, flag := range flags.Flags() {
+		if len(flags.EnvVar(flag)) > 0 {
+			envs = append(envs, flag)
+		}
+	}
+	if len(envs) > 0 {
+		buf.WriteString(".SH ENVIRONMENT\n")
+		for _, flag := range envs {
+			fmt.Fprintf(buf, ".TP\n.B %v\n", roffEscape(flags.EnvVar(flag)))
+			fmt.Fprintf(buf, "Sets %v.\n", roffFlagName(flag))
+		}
+	}
+
+	if len(footer) > 0 {
+		buf.WriteString(".SH NOTES\n")
+		renderRoffParagraphs(buf, footer)
+	}
+
+	_, err := io.Copy(w, buf)
+	return err
+}
+
+// PrintMarkdown prints a generated Markdown reference for the FlagSet to the Writer.
+// The program name is the first word of the usage string and the title of the document.
+// Returns an error if the usage string is empty.
+func (f *Formatter) PrintMarkdown(w io.Writer, usage string, header string, flags FlagSet, footer string) error {
+	if len(usage) == 0 {
+		return errors.New("cli.Formatter.PrintMarkdown: usage string not provided")
+	}
+
+	program, _ := splitUsage(usage)
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "# %v\n", program)
+
+	if len(header) > 0 {
+		buf.WriteByte('\n')
+		buf.WriteString(header)
+		buf.WriteByte('\n')
+	}
+
+	fmt.Fprintf(buf, "\n## %v\n\n```\n%v\n```\n", markdownTitle(f.UsagePrefix), usage)
+
+	if args := flags.Args(); len(args) > 0 {
+		fmt.Fprintf(buf, "\n## %v\n\n", markdownTitle(f.ArgsPrefix))
+		for _, arg := range args {
+			renderMarkdownItem(buf, arg.usage(), arg.Description)
+		}
+	}
+
+	if fl := flags.GroupFlags(""); len(fl) > 0 {
+		fmt.Fprintf(buf, "\n## %v\n\n", markdownTitle(f.FlagsPrefix))
+		for _, flag := range fl {
+			renderMarkdownItem(buf, flagName(flag), describe(flag, &flags))
+		}
+	}
+	for _, group := range flags.Groups() {
+		fmt.Fprintf(buf, "\n## %v\n\n", group)
+		for _, flag := range flags.GroupFlags(group) {
+			renderMarkdownItem(buf, flagName(flag), describe(flag, &flags))
+		}
+	}
+
+	if len(footer) > 0 {
+		buf.WriteByte('\n')
+		buf.WriteString(footer)
+		buf.WriteByte('\n')
+	}
+
+	_, err := io.Copy(w, buf)
+	return err
+}
+
+// splitUsage splits a usage statement into the program name and the rest of the statement.
+func splitUsage(usage string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(usage), " ", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+// flagName returns the unpadded flag name for the Flag, ie. "-o, --opt=value".
+func flagName(flag *Flag) string {
+	var names []string
+	if flag.Short != 0 {
+		names = append(names, ShortPrefix+string(flag.Short))
+	}
+	if len(flag.Long) > 0 {
+		names = append(names, LongPrefix+longName(flag))
+	}
+
+	name := strings.Join(names, commaSeparator)
+	if flag.HasArg {
+		name += argUsage(flag, argName(flag))
+	}
+	return name
+}
+
+// roffFlagName returns the flag name for the Flag with roff font escapes, ie. "\fB\-o\fR, \fB\-\-opt\fR=\fIvalue\fR".
+func roffFlagName(flag *Flag) string {
+	var names []string
+	if flag.Short != 0 {
+		names = append(names, `\fB`+roffEscape(ShortPrefix+string(flag.Short))+`\fR`)
+	}
+	if len(flag.Long) > 0 {
+		names = append(names, `\fB`+roffEscape(LongPrefix+longName(flag))+`\fR`)
+	}
+
+	name := strings.Join(names, commaSeparator)
+	if flag.HasArg {
+		name += argUsage(flag, `\fI`+roffEscape(argName(flag))+`\fR`)
+	}
+	return name
+}
+
+// renderRoffFlags renders a tagged paragraph for each Flag with its description and default value.
+func renderRoffFlags(buf *bytes.Buffer, flags []*Flag) {
+	for _, flag := range flags {
+		fmt.Fprintf(buf, ".TP\n%v\n", roffFlagName(flag))
+		desc := flag.Description
+		if len(flag.Default) > 0 {
+			desc = strings.TrimSpace(desc + " (default: " + flag.Default + ")")
+		}
+		if len(desc) > 0 {
+			buf.WriteString(roffEscape(desc))
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+// renderRoffParagraphs renders the text with each blank line separated block as a paragraph.
+func renderRoffParagraphs(buf *bytes.Buffer, text string) {
+	for i, para := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if i > 0 {
+			buf.WriteString(".PP\n")
+		}
+		for _, line := range strings.Split(strings.TrimSpace(para), "\n") {
+			buf.WriteString(roffEscape(line))
+			buf.WriteByte('\n')
+		}
+	}
+}
+
+// roffEscape escapes backslashes and hyphens, and lines starting with control characters.
+func roffEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// renderMarkdownItem renders a list item with the name as code followed by the description.
+func renderMarkdownItem(buf *bytes.Buffer, name string, desc string) {
+	fmt.Fprintf(buf, "- `%v`", name)
+	if len(desc) > 0 {
+		buf.WriteString(": ")
+		buf.WriteString(desc)
+	}
+	buf.WriteByte('\n')
+}
+
+// markdownTitle returns a block prefix as a section title, ie. "Flags:" as "Flags".
+func markdownTitle(prefix string) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(prefix), ":"))
+}
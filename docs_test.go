@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newDocsFlagSet() *FlagSet {
+	fs := NewFlagSet()
+	fs.EnvPrefix = "TOOL_"
+	fs.AddNewFlag('a', "all", "show all", false)
+	fo, _ := fs.AddNewFlag('o', "output", "the output file", true)
+	fo.ArgName = "FILE"
+	fo.Default = "-"
+	fs.AddNewArg("SRC", "the source", true)
+	return fs
+}
+
+func TestFormatter_PrintMan(t *testing.T) {
+	want := `.TH TOOL 1
+.SH NAME
+tool \- copy files
+.SH SYNOPSIS
+.B tool
+[FLAGS] SRC
+.SH DESCRIPTION
+copy files
+.PP
+\&.dotfiles are included
+.SH ARGUMENTS
+.TP
+\fISRC\fR
+the source
+.SH OPTIONS
+.TP
+\fB\-a\fR, \fB\-\-all\fR
+show all
+.TP
+\fB\-o\fR, \fB\-\-output\fR=\fIFILE\fR
+the output file (default: \-)
+.SH ENVIRONMENT
+.TP
+.B TOOL_ALL
+Sets \fB\-a\fR, \fB\-\-all\fR.
+.TP
+.B TOOL_OUTPUT
+Sets \fB\-o\fR, \fB\-\-output\fR=\fIFILE\fR.
+.SH NOTES
+see cp(1)
+`
+
+	buf := new(bytes.Buffer)
+	err := NewFormatter().PrintMan(buf, "tool [FLAGS] SRC", "copy files\n\n.dotfiles are included", *newDocsFlagSet(), "see cp(1)")
+	if err != nil {
+		t.Fatalf("Formatter.PrintMan() error = %v", err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("Formatter.PrintMan() = %q, want %q", got, want)
+	}
+
+	if err := NewFormatter().PrintMan(buf, "", "", *NewFlagSet(), ""); err == nil {
+		t.Errorf("Formatter.PrintMan() error = nil, want error")
+	}
+}
+
+func TestFormatter_PrintMarkdown(t *testing.T) {
+	want := "# tool\n\ncopy files\n\n## Usage\n\n```\ntool [FLAGS] SRC\n```\n\n## Arguments\n\n- `SRC`: the source\n\n" +
+		"## Flags\n\n- `-a, --all`: show all [env: TOOL_ALL]\n- `-o, --output=FILE`: the output file (default: -) [env: TOOL_OUTPUT]\n\nsee cp(1)\n"
+
+	buf := new(bytes.Buffer)
+	err := NewFormatter().PrintMarkdown(buf, "tool [FLAGS] SRC", "copy files", *newDocsFlagSet(), "see cp(1)")
+	if err != nil {
+		t.Fatalf("Formatter.PrintMarkdown() error = %v", err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("Formatter.PrintMarkdown() = %q, want %q", got, want)
+	}
+}
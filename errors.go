@@ -0,0 +1,298 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by the Parser carry the token and its position in the arguments.
+// Pos is -1 for errors not caused by an argument, ie. an environment variable.
+
+// positioned is implemented by errors that can be located in the arguments by the Parser.
+type positioned interface {
+	setPosition(token string, pos int)
+	pos() int
+}
+
+// position holds the token and position in the arguments that caused an error.
+type position struct {
+	Token string // the argument that caused the error
+	Pos   int    // the index of the argument (-1 if not caused by an argument)
+}
+
+func (p *position) setPosition(token string, pos int) {
+	if p.Pos < 0 {
+		p.Token, p.Pos = token, pos
+	}
+}
+
+func (p *position) pos() int {
+	return p.Pos
+}
+
+// noPosition is the position of errors not yet located in the arguments.
+var noPosition = position{Pos: -1}
+
+// UnknownFlagError is returned when parsing a flag not in the FlagSet.
+type UnknownFlagError struct {
+	position
+	Suggestions []string // similar flags, closest first, ie. "--verbose"
+}
+
+func (e *UnknownFlagError) Error() string {
+	switch len(e.Suggestions) {
+	case 0:
+		return fmt.Sprintf(`unrecognised flag "%v"`, e.Token)
+	case 1:
+		return fmt.Sprintf(`unrecognised flag "%v", did you mean %v?`, e.Token, e.Suggestions[0])
+	}
+	return fmt.Sprintf(`unrecognised flag "%v", did you mean one of %v?`, e.Token, strings.Join(e.Suggestions, ", "))
+}
+
+// AmbiguousFlagError is returned when parsing an abbreviated long flag matching several long flags.
+type AmbiguousFlagError struct {
+	position
+	Candidates []string // the long flags matching the abbreviation, ie. "--verbose"
+}
+
+func (e *AmbiguousFlagError) Error() string {
+	return fmt.Sprintf(`ambiguous flag "%v", could be one of %v`, e.Token, strings.Join(e.Candidates, ", "))
+}
+
+// MissingValueError is returned when parsing a flag without its argument.
+type MissingValueError struct {
+	position
+	Flag *Flag // the flag missing an argument (nil if the flag is unknown)
+}
+
+func (e *MissingValueError) Error() string {
+	// An empty value after the separator, ie. "--opt=".
+	if strings.HasSuffix(e.Token, string(ValueSeparator)) {
+		return fmt.Sprintf(`no value found for "%v" after '%v'`, e.Token, ValueSeparator)
+	}
+	return fmt.Sprintf("missing argument for %v", e.Flag)
+}
+
+// UnexpectedValueError is returned when parsing an argument for a flag that does not accept one.
+type UnexpectedValueError struct {
+	position
+	Flag *Flag // the flag not accepting an argument
+}
+
+func (e *UnexpectedValueError) Error() string {
+	return fmt.Sprintf("%v does not accept an argument", e.Flag)
+}
+
+// DuplicateFlagError is returned when parsing a flag that is not repeatable more than once.
+type DuplicateFlagError struct {
+	position
+	Flag  *Flag  // the flag parsed more than once
+	Value string // the value already parsed for the flag (empty string if a flag was repeated)
+}
+
+func (e *DuplicateFlagError) Error() string {
+	if len(e.Value) > 0 {
+		return fmt.Sprintf(`%v already has a argument "%v"`, e.Flag, e.Value)
+	}
+	return fmt.Sprintf("CommandLine already contains %v", e.Flag)
+}
+
+// MissingRequiredError is returned when required flags are not set.
+type MissingRequiredError struct {
+	Flags []*Flag // the required flags not set
+}
+
+func (e *MissingRequiredError) Error() string {
+	return fmt.Sprintf("missing required flags %v", e.Flags)
+}
+
+// ConstraintError is returned when the flags set violate a Constraint of the FlagSet.
+type ConstraintError struct {
+	Constraint *Constraint
+	Flags      []*Flag // the flags causing the violation
+	Missing    bool    // whether the Flags are missing rather than conflicting
+}
+
+func (e *ConstraintError) Error() string {
+	c := e.Constraint
+	names := flagNames(e.Flags)
+
+	switch {
+	case c.Kind == Requires:
+		return fmt.Sprintf("flag %v requires %v", displayName(c.Flag), names)
+	case c.Kind == Conflicts:
+		return fmt.Sprintf("flag %v can not be used with %v", displayName(c.Flag), names)
+	case e.Missing:
+		return fmt.Sprintf("one of flags %v is required", names)
+	}
+	return fmt.Sprintf("flags %v can not be used together", names)
+}
+
+// InvalidValueError is returned when a value can not be parsed for a flag or positional argument.
+type InvalidValueError struct {
+	position
+	Flag   *Flag  // the flag the value is for (nil for a positional argument)
+	Arg    *Arg   // the positional argument the value is for (nil for a flag)
+	Value  string // the invalid value
+	Source Source // where the value came from
+	EnvVar string // the environment variable the value came from, if any
+	Err    error  // the conversion error (nil if none)
+}
+
+func (e *InvalidValueError) Error() string {
+	buf := new(bytes.Buffer)
+
+	if e.Source == SourceDefault {
+		buf.WriteString("invalid default: ")
+	}
+	fmt.Fprintf(buf, `invalid value "%v" for `, e.Value)
+	if e.Arg != nil {
+		fmt.Fprintf(buf, "argument %v", e.Arg.Name)
+	} else {
+		fmt.Fprint(buf, e.Flag)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(buf, ": %v", e.Err)
+	}
+
+	switch e.Source {
+	case SourceEnv:
+		fmt.Fprintf(buf, " from environment variable %v", e.EnvVar)
+	case SourceConfig:
+		buf.WriteString(" from config")
+	}
+
+	return buf.String()
+}
+
+// Unwrap returns the conversion error.
+func (e *InvalidValueError) Unwrap() error {
+	return e.Err
+}
+
+// MissingArgError is returned when a required positional argument is not parsed.
+// Its position is after the last argument, with an empty Token.
+type MissingArgError struct {
+	position
+	Arg *Arg // the missing positional argument
+}
+
+func (e *MissingArgError) Error() string {
+	return fmt.Sprintf("missing argument %v", e.Arg.Name)
+}
+
+// UnexpectedArgError is returned when parsing more arguments than the positional arguments accept.
+type UnexpectedArgError struct {
+	position
+}
+
+func (e *UnexpectedArgError) Error() string {
+	return fmt.Sprintf(`unexpected argument "%v"`, e.Token)
+}
+
+// UnknownCommandError is returned when parsing an argument that is not a child command,
+// for a command that is not runnable.
+type UnknownCommandError struct {
+	position
+	Command     *Command // the command being parsed
+	Suggestions []string // similar command names, closest first
+}
+
+func (e *UnknownCommandError) Error() string {
+	if len(e.Suggestions) > 0 {
+		return fmt.Sprintf(`unknown command "%v" for "%v", did you mean "%v"?`, e.Token, e.Command.Path(), e.Suggestions[0])
+	}
+	return fmt.Sprintf(`unknown command "%v" for "%v"`, e.Token, e.Command.Path())
+}
+
+// NotRunnableError is returned when executing a command without a Run function.
+type NotRunnableError struct {
+	Command *Command // the command parsed
+}
+
+func (e *NotRunnableError) Error() string {
+	if len(e.Command.commands) > 0 {
+		return fmt.Sprintf(`missing command for "%v"`, e.Command.Path())
+	}
+	return fmt.Sprintf(`command "%v" is not runnable`, e.Command.Path())
+}
+
+// InvalidFlagDefinitionError is returned when adding an invalid flag or positional argument to a FlagSet.
+type InvalidFlagDefinitionError struct {
+	Flag    *Flag  // the invalid flag (nil if not a flag)
+	Arg     *Arg   // the invalid positional argument (nil if not a positional argument)
+	Reason  string // why the definition is invalid
+	Command string // the path of the command parsed (empty string if not parsing commands)
+}
+
+func (e *InvalidFlagDefinitionError) Error() string {
+	if len(e.Command) > 0 {
+		return fmt.Sprintf(`cli.FlagSet: %v in command "%v"`, e.Reason, e.Command)
+	}
+	return "cli.FlagSet: " + e.Reason
+}
+
+// ConfigError is returned when a config file is invalid.
+type ConfigError struct {
+	File string // the config file (empty string if not read from a file)
+	Line int    // the line of the error (0 if unknown)
+	Err  error  // the underlying error
+}
+
+func (e *ConfigError) Error() string {
+	buf := new(bytes.Buffer)
+	if len(e.File) > 0 {
+		buf.WriteString(e.File)
+		buf.WriteString(": ")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(buf, "line %d: ", e.Line)
+	}
+	buf.WriteString(e.Err.Error())
+	return buf.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ResponseFileError is returned when a response file can not be read,
+// or an argument read from a response file is invalid.
+type ResponseFileError struct {
+	File string // the response file (empty string for the command line)
+	Line int    // the line of the argument (0 if unknown)
+	Err  error  // the underlying error
+}
+
+func (e *ResponseFileError) Error() string {
+	buf := new(bytes.Buffer)
+	if len(e.File) > 0 {
+		buf.WriteString(e.File)
+		buf.WriteString(": ")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(buf, "line %d: ", e.Line)
+	}
+	buf.WriteString(e.Err.Error())
+	return buf.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *ResponseFileError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownKeyError is returned when a config key is not a long flag.
+type UnknownKeyError struct {
+	Key         string   // the unknown key
+	Suggestions []string // long flags similar to the key
+}
+
+func (e *UnknownKeyError) Error() string {
+	if len(e.Suggestions) > 0 {
+		return fmt.Sprintf(`unknown key "%v", did you mean "%v"?`, e.Key, strings.Join(e.Suggestions, `", "`))
+	}
+	return fmt.Sprintf(`unknown key "%v"`, e.Key)
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParser_ParseArgs_errors(t *testing.T) {
+	fs := NewFlagSet()
+	fa, _ := fs.AddNewFlag('a', "all", "", false)
+	fp, _ := fs.AddNewFlag('p', "port", "", true)
+	fp.Type = TypeInt
+	fe, _ := fs.AddNewFlag(0, "env", "", true)
+	fe.Type = TypeInt
+	fe.EnvVar = "CLI_TEST_ERRORS_ENV"
+	fr, _ := fs.AddNewRequiredFlag('r', "req", "", false)
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     string
+		target  interface{}
+		want    string
+		wantPos int
+	}{
+		{"unknown", []string{"-r", "--none"}, "", new(*UnknownFlagError), `unrecognised flag "--none"`, 1},
+		{"missing value", []string{"-r", "-p"}, "", new(*MissingValueError), "missing argument for " + fp.String(), 1},
+		{"missing value before flag", []string{"-p", "-r"}, "", new(*MissingValueError), "missing argument for " + fp.String(), 1},
+		{"empty value", []string{"-r", "--port="}, "", new(*MissingValueError), fmt.Sprintf(`no value found for "--port=" after '%v'`, ValueSeparator), 1},
+		{"duplicate", []string{"-r", "-a", "--all"}, "", new(*DuplicateFlagError), "CommandLine already contains " + fa.String(), 2},
+		{"invalid value", []string{"-r", "--port=x"}, "", new(*InvalidValueError), `invalid value "x" for ` + fp.String() + ": invalid syntax", 1},
+		{"missing required", []string{"-a"}, "", new(*MissingRequiredError), "missing required flags [" + fr.String() + "]", -1},
+		{"invalid env", []string{"-r"}, "x", new(*InvalidValueError), `invalid value "x" for ` + fe.String() + ": invalid syntax from environment variable CLI_TEST_ERRORS_ENV", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.env) > 0 {
+				os.Setenv("CLI_TEST_ERRORS_ENV", tt.env)
+				defer os.Unsetenv("CLI_TEST_ERRORS_ENV")
+			}
+
+			_, err := NewParser().ParseArgs(fs, tt.args)
+			if err == nil {
+				t.Fatalf("Parser.ParseArgs() error = nil, want %v", tt.want)
+			}
+			if !errors.As(err, tt.target) {
+				t.Fatalf("Parser.ParseArgs() error = %T, want %T", err, tt.target)
+			}
+			if got := err.Error(); got != tt.want {
+				t.Errorf("Parser.ParseArgs() error = %v, want %v", got, tt.want)
+			}
+
+			var pos int
+			switch e := reflect.ValueOf(tt.target).Elem().Interface().(type) {
+			case *UnknownFlagError:
+				pos = e.Pos
+			case *MissingValueError:
+				pos = e.Pos
+			case *DuplicateFlagError:
+				pos = e.Pos
+			case *InvalidValueError:
+				pos = e.Pos
+			default:
+				pos = -1
+			}
+			if pos != tt.wantPos {
+				t.Errorf("Parser.ParseArgs() error position = %v, want %v", pos, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestInvalidValueError_Unwrap(t *testing.T) {
+	fs := NewFlagSet()
+	fp, _ := fs.AddNewFlag('p', "port", "", true)
+	fp.Type = TypeInt
+
+	_, err := NewParser().ParseArgs(fs, []string{"-p", "99999999999999999999"})
+	if !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("Parser.ParseArgs() error = %v, want %v", err, strconv.ErrRange)
+	}
+
+	var e *InvalidValueError
+	if !errors.As(err, &e) || e.Flag != fp || e.Token != "99999999999999999999" || e.Pos != 1 {
+		t.Errorf("Parser.ParseArgs() error = %#v, want flag, token and position", e)
+	}
+}
+
+func TestFlagSet_AddFlag_error(t *testing.T) {
+	fs := NewFlagSet()
+	f := NewFlag('5', "", "", false)
+
+	err := fs.AddFlag(f)
+
+	var e *InvalidFlagDefinitionError
+	if !errors.As(err, &e) || e.Flag != f {
+		t.Fatalf("FlagSet.AddFlag() error = %v, want *InvalidFlagDefinitionError", err)
+	}
+	if want := "cli.FlagSet: short flag '53' is not a letter"; err.Error() != want {
+		t.Errorf("FlagSet.AddFlag() error = %v, want %v", err, want)
+	}
+}
+
+func TestUnknownFlagError_suggestions(t *testing.T) {
+	fs := NewFlagSet()
+	fs.AddNewFlag('v', "verbose", "", false)
+	fs.AddNewFlag(0, "verse", "", false)
+	fs.AddNewFlag(0, "output", "", true)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"typo", []string{"--verbosee"}, `unrecognised flag "--verbosee", did you mean --verbose?`},
+		{"several", []string{"--verbse"}, `unrecognised flag "--verbse", did you mean one of --verbose, --verse?`},
+		{"with value", []string{"--outptu=x"}, `unrecognised flag "--outptu=x", did you mean --output?`},
+		{"single dash long", []string{"-output"}, `unrecognised flag "-output", did you mean --output?`},
+		{"short case", []string{"-V"}, `unrecognised flag "-V", did you mean -v?`},
+		{"none", []string{"--zzz"}, `unrecognised flag "--zzz"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser().ParseArgs(fs, tt.args)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Parser.ParseArgs() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
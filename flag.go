@@ -2,13 +2,14 @@ package cli
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
+	"strings"
 	"unicode"
 )
 
 const (
-	defaultArgName    = "ARG"
-	minLongFlagLength = 2
+	defaultArgName = "ARG"
 )
 
 // Flag represents a command line flag, with a short and/or long variation.
@@ -17,10 +18,25 @@ type Flag struct {
 	Long        string // the long flag (empty string for no long flag)
 	Description string // the flag description
 
-	Required bool // true if flag is required
-	HasArg   bool // true if the flag has an argument
+	Required    bool // true if flag is required
+	HasArg      bool // true if the flag has an argument
+	Repeatable  bool // true if the flag may be parsed more than once
+	OptionalArg bool // true if the argument may be omitted, it must then be attached, ie. --color=always or -O2
+	Negatable   bool // true if a long flag without an argument also accepts --no-flag and --flag=true/false
 
-	ArgName string // the argument name for the help formatter
+	ArgName string    // the argument name for the help formatter
+	Type    ValueType // the type the argument is converted to when parsed
+	Value   Value     // the custom value the argument is parsed by (nil for none)
+
+	EnvVar  string // the environment variable used if the flag is not parsed (empty string for none)
+	Default string // the value used if the flag is not set (empty string for none)
+
+	Choices      []string // the values the argument must be one of (nil for any value)
+	FoldChoices  bool     // true if choices are matched ignoring case
+	PrefixChoice bool     // true if a unique prefix of a choice is accepted
+
+	Completion Completion // how the argument is completed by the shell
+	Group      string     // the help section the flag is listed in (empty string for the default section)
 }
 
 // NewFlag constructs a new flag.
@@ -47,6 +63,64 @@ func NewRequiredFlag(short rune, long string, desc string, hasArg bool) *Flag {
 	}
 }
 
+// NewValueFlag constructs a new flag with an argument parsed by the Value.
+func NewValueFlag(short rune, long string, desc string, value Value) *Flag {
+	return &Flag{
+		Short:       short,
+		Long:        long,
+		Description: desc,
+		Required:    false,
+		HasArg:      true,
+		ArgName:     defaultArgName,
+		Value:       value,
+	}
+}
+
+// NewChoiceFlag constructs a new flag with an argument that must be one of the choices.
+func NewChoiceFlag(short rune, long string, desc string, choices ...string) *Flag {
+	return &Flag{
+		Short:       short,
+		Long:        long,
+		Description: desc,
+		Required:    false,
+		HasArg:      true,
+		ArgName:     defaultArgName,
+		Choices:     choices,
+	}
+}
+
+// choose returns the choice matching the value, or an error if it matches none or several choices.
+func (f *Flag) choose(value string) (string, error) {
+	if len(f.Choices) == 0 {
+		return value, nil
+	}
+
+	equal := func(a, b string) bool {
+		if f.FoldChoices {
+			return strings.EqualFold(a, b)
+		}
+		return a == b
+	}
+
+	var matches []string
+	for _, choice := range f.Choices {
+		if equal(choice, value) {
+			return choice, nil
+		}
+		if f.PrefixChoice && len(value) > 0 && len(value) < len(choice) && equal(choice[:len(value)], value) {
+			matches = append(matches, choice)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("must be one of %v", strings.Join(f.Choices, ", "))
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("ambiguous, could be one of %v", strings.Join(matches, ", "))
+}
+
 // String returns a string representation of this flag.
 func (f Flag) String() string {
 	buf := new(bytes.Buffer)
@@ -69,10 +143,23 @@ func (f Flag) String() string {
 	buf.WriteString(strconv.FormatBool(f.Required))
 	buf.WriteString(", HasArg=")
 	buf.WriteString(strconv.FormatBool(f.HasArg))
+	if f.Repeatable {
+		buf.WriteString(", Repeatable=true")
+	}
+	if f.Negatable {
+		buf.WriteString(", Negatable=true")
+	}
 	if f.HasArg {
 		buf.WriteString(", ArgName=\"")
 		buf.WriteString(f.ArgName)
 		buf.WriteRune('"')
+		if f.Value != nil {
+			buf.WriteString(", Value=")
+			buf.WriteString(f.Value.Type())
+		} else if f.Type != TypeString {
+			buf.WriteString(", Type=")
+			buf.WriteString(f.Type.String())
+		}
 	}
 	buf.WriteRune('}')
 
@@ -6,14 +6,14 @@ import (
 )
 
 func TestNewFlag(t *testing.T) {
-	want := &Flag{'a', "", "", false, false, defaultArgName}
+	want := &Flag{Short: 'a', ArgName: defaultArgName}
 	if got := NewFlag('a', "", "", false); !reflect.DeepEqual(got, want) {
 		t.Errorf("NewFlag() = %v, want %v", got, want)
 	}
 }
 
 func TestNewRequiredFlag(t *testing.T) {
-	want := &Flag{'a', "", "", true, false, defaultArgName}
+	want := &Flag{Short: 'a', Required: true, ArgName: defaultArgName}
 	if got := NewRequiredFlag('a', "", "", false); !reflect.DeepEqual(got, want) {
 		t.Errorf("NewFlag() = %v, want %v", got, want)
 	}
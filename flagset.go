@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -10,9 +9,25 @@ import (
 
 // FlagSet represents a collection of Flags to be parsed by a Parser.
 type FlagSet struct {
-	shorts   map[rune]*Flag
-	longs    map[string]*Flag
-	required []*Flag
+	// EnvPrefix enables environment variables for long flags without an EnvVar,
+	// ie. "APP_" maps --dry-run to APP_DRY_RUN (empty string for disabled).
+	EnvPrefix string
+
+	// Naming is the policy long flag names follow (nil for DefaultNamingPolicy).
+	// It must be set before any flags are added, and be the same for the flags of a command
+	// and the persistent flags it inherits.
+	Naming *NamingPolicy
+
+	// GroupOrder is the order flag groups are printed in help, groups not listed
+	// follow in the order they were first added (nil for declaration order).
+	GroupOrder []string
+
+	shorts      map[rune]*Flag
+	longs       map[string]*Flag
+	required    []*Flag
+	declared    []*Flag       // all flags, in the order added
+	args        []*Arg        // positional arguments, in order
+	constraints []*Constraint // relationships between flags, checked after parsing
 }
 
 // NewFlagSet constructs and returns a new empty FlagSet.
@@ -31,36 +46,37 @@ func (f *FlagSet) AddFlag(flag *Flag) error {
 
 	// Make sure neither flag is invalid before adding either.
 	if flag.Short != 0 {
-		if !unicode.IsLetter(flag.Short) {
-			return fmt.Errorf("cli.FlagSet: short flag '%v' is not a letter", flag.Short)
+		if reason := f.naming().validateShort(flag.Short); len(reason) > 0 {
+			return &InvalidFlagDefinitionError{Flag: flag, Reason: reason}
 		}
 		if _, ok := f.shorts[flag.Short]; ok {
-			return fmt.Errorf("cli.FlagSet: short flag '%v' already exists", flag.Short)
+			return &InvalidFlagDefinitionError{Flag: flag, Reason: fmt.Sprintf("short flag '%v' already exists", flag.Short)}
 		}
 
 		s = true
 	}
 	if len(flag.Long) != 0 {
-		flag.Long = strings.ToLower(flag.Long)
+		naming := f.naming()
+		flag.Long = naming.fold(flag.Long)
 
-		if i := strings.IndexFunc(flag.Long, func(r rune) bool {
-			return !unicode.IsLetter(r)
-		}); i > -1 {
-			return fmt.Errorf(`cli.FlagSet: long flag "%v" contains a non letter '%v'`,
-				flag.Long, []rune(flag.Long)[i])
+		if reason := naming.validate(flag.Long); len(reason) > 0 {
+			return &InvalidFlagDefinitionError{Flag: flag, Reason: reason}
 		}
 		if _, ok := f.longs[flag.Long]; ok {
-			return fmt.Errorf(`cli.FlagSet: long flag "%v" already exists`, flag.Long)
-		}
-		if len([]rune(flag.Long)) < minLongFlagLength {
-			return fmt.Errorf(`cli.FlagSet: long flag "%v" must be %d or more letters`, flag.Long, minLongFlagLength)
+			return &InvalidFlagDefinitionError{Flag: flag, Reason: fmt.Sprintf(`long flag "%v" already exists`, flag.Long)}
 		}
 
 		l = true
 	}
 	// Flag must have a short or long variation, or both.
 	if !(s || l) {
-		return errors.New("cli.FlagSet: no short or long flag specified")
+		return &InvalidFlagDefinitionError{Flag: flag, Reason: "no short or long flag specified"}
+	}
+	if flag.OptionalArg && !flag.HasArg {
+		return &InvalidFlagDefinitionError{Flag: flag, Reason: "optional argument flag has no argument"}
+	}
+	if flag.Negatable && (flag.HasArg || !l) {
+		return &InvalidFlagDefinitionError{Flag: flag, Reason: "negatable flag must be a long flag without an argument"}
 	}
 
 	if s {
@@ -72,6 +88,7 @@ func (f *FlagSet) AddFlag(flag *Flag) error {
 	if flag.Required {
 		f.required = append(f.required, flag)
 	}
+	f.declared = append(f.declared, flag)
 
 	return nil
 }
@@ -104,6 +121,88 @@ func (f *FlagSet) AddNewRequiredFlag(short rune, long string, desc string, hasAr
 	return flag, nil
 }
 
+// AddNewValueFlag creates a new Flag with an argument parsed by the Value and adds it to the FlagSet.
+// Returns the created Flag, or an error if the short/long flag is invalid or already exists.
+func (f *FlagSet) AddNewValueFlag(short rune, long string, desc string, value Value) (*Flag, error) {
+	flag := NewValueFlag(short, long, desc, value)
+
+	err := f.AddFlag(flag)
+	if err != nil {
+		flag = nil
+		return nil, err
+	}
+
+	return flag, nil
+}
+
+// AddNewChoiceFlag creates a new Flag with an argument that must be one of the choices and adds it to the FlagSet.
+// Returns the created Flag, or an error if the short/long flag is invalid or already exists.
+func (f *FlagSet) AddNewChoiceFlag(short rune, long string, desc string, choices ...string) (*Flag, error) {
+	flag := NewChoiceFlag(short, long, desc, choices...)
+
+	err := f.AddFlag(flag)
+	if err != nil {
+		flag = nil
+		return nil, err
+	}
+
+	return flag, nil
+}
+
+// AddArg adds the specified positional argument to the FlagSet, after those already added.
+// Returns an error if the argument name is empty or already exists,
+// a required argument follows an optional one, or more than one argument is variadic.
+// Arguments other than a variadic argument must be required, if one exists.
+func (f *FlagSet) AddArg(arg *Arg) error {
+	if len(arg.Name) == 0 {
+		return &InvalidFlagDefinitionError{Arg: arg, Reason: "argument has no name"}
+	}
+
+	variadic := arg.Variadic
+	for _, a := range f.args {
+		if a.Name == arg.Name {
+			return &InvalidFlagDefinitionError{Arg: arg, Reason: fmt.Sprintf(`argument "%v" already exists`, arg.Name)}
+		}
+		if a.Variadic && arg.Variadic {
+			return &InvalidFlagDefinitionError{Arg: arg, Reason: fmt.Sprintf(`argument "%v" is variadic but "%v" already is`, arg.Name, a.Name)}
+		}
+		if !a.Required && arg.Required {
+			return &InvalidFlagDefinitionError{Arg: arg, Reason: fmt.Sprintf(`required argument "%v" follows optional argument "%v"`, arg.Name, a.Name)}
+		}
+		variadic = variadic || a.Variadic
+	}
+
+	// A variadic argument makes optional arguments ambiguous.
+	if variadic {
+		for _, a := range append(f.args, arg) {
+			if !a.Variadic && !a.Required {
+				return &InvalidFlagDefinitionError{Arg: arg, Reason: fmt.Sprintf(`optional argument "%v" with a variadic argument`, a.Name)}
+			}
+		}
+	}
+
+	f.args = append(f.args, arg)
+	return nil
+}
+
+// AddNewArg creates a new positional argument and adds it to the FlagSet.
+// Returns the created Arg, or an error if the argument is invalid, see AddArg.
+func (f *FlagSet) AddNewArg(name string, desc string, required bool) (*Arg, error) {
+	arg := NewArg(name, desc, required)
+
+	if err := f.AddArg(arg); err != nil {
+		return nil, err
+	}
+	return arg, nil
+}
+
+// Args returns a slice with the positional arguments in this FlagSet, in order.
+func (f *FlagSet) Args() []*Arg {
+	args := make([]*Arg, len(f.args))
+	copy(args, f.args)
+	return args
+}
+
 // Flags returns a slice with all the Flags in this FlagSet.
 func (f *FlagSet) Flags() []*Flag {
 	minCap := len(f.shorts)
@@ -126,6 +225,45 @@ func (f *FlagSet) Flags() []*Flag {
 	return flags
 }
 
+// Groups returns the names of the flag groups in this FlagSet, in the order they are printed in help.
+// Groups in GroupOrder come first, followed by the remaining groups in the order they were first added.
+// Flags without a group are not included.
+func (f *FlagSet) Groups() []string {
+	var groups []string
+	seen := make(map[string]bool)
+
+	present := make(map[string]bool)
+	for _, flag := range f.declared {
+		present[flag.Group] = true
+	}
+
+	for _, group := range f.GroupOrder {
+		if present[group] && !seen[group] && len(group) > 0 {
+			groups = append(groups, group)
+			seen[group] = true
+		}
+	}
+	for _, flag := range f.declared {
+		if !seen[flag.Group] && len(flag.Group) > 0 {
+			groups = append(groups, flag.Group)
+			seen[flag.Group] = true
+		}
+	}
+	return groups
+}
+
+// GroupFlags returns a slice with the Flags in the named group, or without a group for an empty string.
+func (f *FlagSet) GroupFlags(group string) []*Flag {
+	var flags []*Flag
+	for _, flag := range f.declared {
+		if flag.Group == group {
+			flags = append(flags, flag)
+		}
+	}
+	sort.Sort(FlagSlice(flags))
+	return flags
+}
+
 // ShortFlags returns a slice with all the short Flags in this FlagSet.
 func (f *FlagSet) ShortFlags() []*Flag {
 	flags := make([]*Flag, 0, len(f.shorts))
@@ -154,6 +292,24 @@ func (f *FlagSet) RequiredFlags() []*Flag {
 	return flags
 }
 
+// EnvVar returns the name of the environment variable used for the Flag if it is not parsed.
+// Returns the EnvVar of the Flag if set, otherwise the EnvPrefix followed by the
+// uppercase long flag with other than letters and digits replaced by '_', or an empty string for none.
+func (f *FlagSet) EnvVar(flag *Flag) string {
+	if len(flag.EnvVar) > 0 {
+		return flag.EnvVar
+	}
+	if len(f.EnvPrefix) == 0 || len(flag.Long) == 0 {
+		return ""
+	}
+	return f.EnvPrefix + strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, flag.Long)
+}
+
 // Lookup returns the Flag with the long or short name specified.
 // Returns (Flag, true) if a matching Flag was found.
 // Or (nil, false) if no matching Flag was found.
@@ -163,34 +319,39 @@ func (f *FlagSet) Lookup(name string) (*Flag, bool) {
 		if flag, ok := f.shorts[s]; ok {
 			return flag, ok
 		}
-		return nil, false
-	}
-
-	name = strings.ToLower(name)
-	if flag, ok := f.longs[name]; ok {
-		return flag, ok
 	}
 
-	return nil, false
+	// Single letter long flags are allowed by a NamingPolicy with a MinLength of 1.
+	return f.long(name)
 }
 
 // Matches returns long flags starting with the name specified.
-// Casing is ignored for long flags and the slice will be sorted lexicographically.
+// Casing is ignored for long flags unless the NamingPolicy is CaseSensitive,
+// and the slice will be sorted lexicographically.
 // If a perfect match is found only that match will be returned.
 func (f *FlagSet) Matches(name string) []string {
 	if _, ok := f.Lookup(name); ok {
 		if len([]rune(name)) > 1 {
-			// Lowercase if long flag.
-			name = strings.ToLower(name)
+			// Stored form if long flag.
+			name = f.naming().fold(name)
 		}
 		return []string{name}
 	}
 
+	return f.longMatches(name)
+}
+
+// longMatches returns the long flags starting with the name specified, sorted lexicographically.
+// Unlike Matches a single character name is never a short flag, ie. "v" matches --verbose.
+func (f *FlagSet) longMatches(name string) []string {
+	if flag, ok := f.long(name); ok {
+		return []string{flag.Long}
+	}
+
 	var ret []string
 
-	name = strings.ToLower(name)
 	for _, flag := range f.longs {
-		if strings.HasPrefix(flag.Long, name) {
+		if f.naming().hasPrefix(flag.Long, name) {
 			ret = append(ret, flag.Long)
 		}
 	}
@@ -73,7 +73,7 @@ func TestFlagSet_AddFlag(t *testing.T) {
 		{
 			"long too short",
 			NewFlagSet(),
-			args{NewFlag(0, "long"[:minLongFlagLength-1], "", false)},
+			args{NewFlag(0, "long"[:DefaultNamingPolicy.MinLength-1], "", false)},
 			true,
 		},
 		{
@@ -154,7 +154,7 @@ func TestFlagSet_AddNewFlag(t *testing.T) {
 		{
 			"long too short",
 			NewFlagSet(),
-			args{0, "long"[:minLongFlagLength-1], "", false},
+			args{0, "long"[:DefaultNamingPolicy.MinLength-1], "", false},
 			nil,
 			true,
 		},
@@ -242,7 +242,7 @@ func TestFlagSet_AddNewRequiredFlag(t *testing.T) {
 		{
 			"long too short",
 			NewFlagSet(),
-			args{0, "long"[:minLongFlagLength-1], "", false},
+			args{0, "long"[:DefaultNamingPolicy.MinLength-1], "", false},
 			nil,
 			true,
 		},
@@ -384,3 +384,90 @@ func TestFlagSet_Matches(t *testing.T) {
 		})
 	}
 }
+
+func TestFlagSet_EnvVar(t *testing.T) {
+	f := NewFlagSet()
+	fa, _ := f.AddNewFlag('a', "dryrun", "", false)
+	fb, _ := f.AddNewFlag('b', "", "", false)
+	fc, _ := f.AddNewFlag(0, "ccc", "", false)
+	fc.EnvVar = "CCC"
+
+	tests := []struct {
+		name   string
+		prefix string
+		flag   *Flag
+		want   string
+	}{
+		{"no prefix", "", fa, ""},
+		{"prefix", "APP_", fa, "APP_DRYRUN"},
+		{"short only", "APP_", fb, ""},
+		{"explicit", "APP_", fc, "CCC"},
+		{"explicit no prefix", "", fc, "CCC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.EnvPrefix = tt.prefix
+			if got := f.EnvVar(tt.flag); got != tt.want {
+				t.Errorf("FlagSet.EnvVar() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagSet_AddArg(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []*Arg
+		wantErr bool
+	}{
+		{"valid", []*Arg{NewArg("A", "", true), NewArg("B", "", false)}, false},
+		{"variadic middle", []*Arg{NewVariadicArg("A", "", true), NewArg("B", "", true)}, false},
+		{"no name", []*Arg{NewArg("", "", true)}, true},
+		{"duplicate", []*Arg{NewArg("A", "", true), NewArg("A", "", true)}, true},
+		{"required after optional", []*Arg{NewArg("A", "", false), NewArg("B", "", true)}, true},
+		{"two variadic", []*Arg{NewVariadicArg("A", "", true), NewVariadicArg("B", "", true)}, true},
+		{"optional before variadic", []*Arg{NewArg("A", "", false), NewVariadicArg("B", "", false)}, true},
+		{"optional after variadic", []*Arg{NewVariadicArg("A", "", true), NewArg("B", "", false)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlagSet()
+
+			var err error
+			for _, arg := range tt.args {
+				if err = f.AddArg(arg); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FlagSet.AddArg() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFlagSet_Groups(t *testing.T) {
+	tests := []struct {
+		name  string
+		order []string
+		want  []string
+	}{
+		{"declaration order", nil, []string{"Output", "Networking", "Debug"}},
+		{"custom order", []string{"Debug", "Networking"}, []string{"Debug", "Networking", "Output"}},
+		{"unknown group", []string{"Missing", "Debug"}, []string{"Debug", "Output", "Networking"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlagSet()
+			f.GroupOrder = tt.order
+			for i, group := range []string{"Output", "", "Networking", "Output", "Debug"} {
+				flag := NewFlag(0, "flag"+string(rune('a'+i)), "", false)
+				flag.Group = group
+				f.AddFlag(flag)
+			}
+			if got := f.Groups(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FlagSet.Groups() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
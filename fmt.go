@@ -25,27 +25,40 @@ const (
 	// Default prefix to the flags block.
 	defaultFlagsPrefix = "Flags:"
 
+	// Default prefix to the arguments block.
+	defaultArgsPrefix = "Arguments:"
+
+	// Default prefix to the flag constraints block.
+	defaultConstraintsPrefix = "Constraints:"
+
+	// Placeholder for the flags in a generated usage statement.
+	usageFlags = "[FLAGS]"
+
 	// Separator for short and long settings ie. -o, --opt.
 	commaSeparator = ", "
 )
 
 // Formatter is a utility for formatting a help string for a FlagSet.
 type Formatter struct {
-	Width       int
-	FlagPad     int
-	DescPad     int
-	UsagePrefix string
-	FlagsPrefix string
+	Width             int
+	FlagPad           int
+	DescPad           int
+	UsagePrefix       string
+	FlagsPrefix       string
+	ArgsPrefix        string
+	ConstraintsPrefix string
 }
 
 // NewFormatter constructs a new Formatter with the default values.
 func NewFormatter() *Formatter {
 	f := &Formatter{
-		Width:       defaultWidth,
-		FlagPad:     defaultFlagPad,
-		DescPad:     defaultDescPad,
-		UsagePrefix: defaultUsagePrefix,
-		FlagsPrefix: defaultFlagsPrefix,
+		Width:             defaultWidth,
+		FlagPad:           defaultFlagPad,
+		DescPad:           defaultDescPad,
+		UsagePrefix:       defaultUsagePrefix,
+		FlagsPrefix:       defaultFlagsPrefix,
+		ArgsPrefix:        defaultArgsPrefix,
+		ConstraintsPrefix: defaultConstraintsPrefix,
 	}
 	return f
 }
@@ -63,7 +76,9 @@ func (f *Formatter) PrintHelp(w io.Writer, usage string, header string, flags Fl
 		f.printWrapped(w, header)
 	}
 
+	f.PrintArgs(w, flags)
 	f.PrintFlags(w, flags)
+	f.PrintConstraints(w, flags)
 
 	if len(footer) > 0 {
 		fmt.Fprintln(w)
@@ -85,6 +100,31 @@ func (f *Formatter) PrintUsage(w io.Writer, usage string) {
 	f.printWrappedIndent(w, buf.String(), len(f.UsagePrefix)+argPos)
 }
 
+// Usage returns a generated usage statement for the program and the FlagSet,
+// ie. "tool [FLAGS] SRC... DEST", for use with PrintUsage and PrintHelp.
+func (f *Formatter) Usage(program string, flags FlagSet) string {
+	buf := bytes.NewBufferString(program)
+
+	if len(flags.Flags()) > 0 {
+		buf.WriteByte(' ')
+		buf.WriteString(usageFlags)
+	}
+	for _, arg := range flags.Args() {
+		buf.WriteByte(' ')
+		buf.WriteString(arg.usage())
+	}
+
+	return buf.String()
+}
+
+// PrintArgs prints a generated message detailing the positional arguments in the FlagSet to the Writer.
+func (f *Formatter) PrintArgs(w io.Writer, flags FlagSet) {
+	buf := new(bytes.Buffer)
+
+	f.renderArgs(buf, flags)
+	fmt.Fprint(w, buf.String())
+}
+
 // PrintFlags prints a generated message detailing the flags in the FlagSet to the Writer.
 func (f *Formatter) PrintFlags(w io.Writer, flags FlagSet) {
 	buf := new(bytes.Buffer)
@@ -93,6 +133,14 @@ func (f *Formatter) PrintFlags(w io.Writer, flags FlagSet) {
 	fmt.Fprint(w, buf.String())
 }
 
+// PrintConstraints prints a generated message detailing the constraints between flags in the FlagSet to the Writer.
+func (f *Formatter) PrintConstraints(w io.Writer, flags FlagSet) {
+	buf := new(bytes.Buffer)
+
+	f.renderConstraints(buf, flags)
+	fmt.Fprint(w, buf.String())
+}
+
 // printWrappedIndent prints text to the Writer with line wrapping using Formatter width.
 func (f *Formatter) printWrapped(w io.Writer, text string) {
 	f.printWrappedIndent(w, text, 0)
@@ -107,83 +155,137 @@ func (f *Formatter) printWrappedIndent(w io.Writer, text string, newLineIndent i
 	fmt.Fprintln(w, buf.String())
 }
 
+// renderFlags renders the flags without a group, followed by a section for each flag group.
+// Descriptions are aligned within each section.
 func (f *Formatter) renderFlags(buf *bytes.Buffer, fs FlagSet) *bytes.Buffer {
+	f.renderFlagSection(buf, f.FlagsPrefix, fs.GroupFlags(""), &fs)
+	for _, group := range fs.Groups() {
+		f.renderFlagSection(buf, group+":", fs.GroupFlags(group), &fs)
+	}
+	return buf
+}
+
+func (f *Formatter) renderFlagSection(buf *bytes.Buffer, title string, flags []*Flag, fs *FlagSet) *bytes.Buffer {
+	if len(flags) == 0 {
+		return buf
+	}
+
+	names := make([]string, 0, len(flags))
+	descs := make([]string, 0, len(flags))
+	for _, flag := range flags {
+		names = append(names, f.renderFlagName(flag))
+		descs = append(descs, describe(flag, fs))
+	}
+
+	buf.WriteByte('\n')
+	buf.WriteString(title)
+	buf.WriteByte('\n')
+
+	return f.renderColumns(buf, names, descs)
+}
+
+// renderFlagName returns the padded flag column for the Flag, ie. "  -o, --opt=value".
+func (f *Formatter) renderFlagName(flag *Flag) string {
 	flagPad := createPad(f.FlagPad)              // padding before short flag
 	optPad := createPad(2 + len(commaSeparator)) // padding to fill if no short flag is present
-	descPad := createPad(f.DescPad)              // padding before description
 
-	// The length of the longest flag ie. len("  -o, --opt=value")=17.
-	// Used in description alignment.
-	maxLen := 0
+	fBuf := new(bytes.Buffer)
 
-	flags := fs.Flags()
-	info := make([]string, 0, len(flags))
+	fBuf.WriteString(flagPad) // add initial padding
 
-	if len(flags) == 0 {
+	if flag.Short == 0 && len(flag.Long) == 0 {
+		panic(fmt.Sprintf("cli.renderFlags: %s has no short or long option", flag))
+	}
+
+	if flag.Short != 0 {
+		fBuf.WriteString(ShortPrefix)
+		fBuf.WriteRune(flag.Short)
+
+		if len(flag.Long) > 1 {
+			// Add separator if long option exists.
+			fBuf.WriteString(commaSeparator)
+		}
+	} else {
+		// No short option add padding to align long option.
+		fBuf.WriteString(optPad)
+	}
+
+	if len(flag.Long) > 1 {
+		fBuf.WriteString(LongPrefix)
+		fBuf.WriteString(longName(flag))
+	}
+
+	if flag.HasArg {
+		fBuf.WriteString(argUsage(flag, argName(flag)))
+	}
+
+	return fBuf.String()
+}
+
+func (f *Formatter) renderArgs(buf *bytes.Buffer, fs FlagSet) *bytes.Buffer {
+	args := fs.Args()
+	if len(args) == 0 {
 		return buf
 	}
 
+	names := make([]string, 0, len(args))
+	descs := make([]string, 0, len(args))
+	for _, arg := range args {
+		names = append(names, createPad(f.FlagPad)+arg.Name)
+		descs = append(descs, arg.Description)
+	}
+
 	buf.WriteByte('\n')
-	buf.WriteString(f.FlagsPrefix)
+	buf.WriteString(f.ArgsPrefix)
 	buf.WriteByte('\n')
 
-	for _, flag := range flags {
-		fBuf := new(bytes.Buffer)
+	return f.renderColumns(buf, names, descs)
+}
 
-		fBuf.WriteString(flagPad) // add initial padding
+func (f *Formatter) renderConstraints(buf *bytes.Buffer, fs FlagSet) *bytes.Buffer {
+	constraints := fs.Constraints()
+	if len(constraints) == 0 {
+		return buf
+	}
 
-		if flag.Short == 0 && len(flag.Long) == 0 {
-			panic(fmt.Sprintf("cli.renderFlags: %s has no short or long option", flag))
-		}
+	buf.WriteByte('\n')
+	buf.WriteString(f.ConstraintsPrefix)
+	buf.WriteByte('\n')
 
-		if flag.Short != 0 {
-			fBuf.WriteString(ShortPrefix)
-			fBuf.WriteRune(flag.Short)
+	for _, c := range constraints {
+		f.renderWrappedText(buf, createPad(f.FlagPad)+c.String(), f.FlagPad*2)
+		buf.WriteByte('\n')
+	}
 
-			if len(flag.Long) > 1 {
-				// Add separator if long option exists.
-				fBuf.WriteString(commaSeparator)
-			}
-		} else {
-			// No short option add padding to align long option.
-			fBuf.WriteString(optPad)
-		}
+	return buf
+}
 
-		if len(flag.Long) > 1 {
-			fBuf.WriteString(LongPrefix)
-			fBuf.WriteString(flag.Long)
-		}
+// renderColumns renders each name followed by its description, with the descriptions aligned.
+func (f *Formatter) renderColumns(buf *bytes.Buffer, names []string, descs []string) *bytes.Buffer {
+	descPad := createPad(f.DescPad) // padding before description
 
-		if flag.HasArg {
-			sep := ValueSeparator
-			if len(flag.Long) == 0 {
-				sep = ' '
-			}
-			fBuf.WriteRune(sep)
-			fBuf.WriteString(flag.ArgName)
-		}
+	// The length of the longest name ie. len("  -o, --opt=value")=17.
+	// Used in description alignment.
+	maxLen := 0
 
-		s := fBuf.String()
+	for _, s := range names {
 		if len(s) > maxLen && len(s) < f.Width*2/5 {
 			maxLen = len(s)
 		}
-
-		info = append(info, s)
 	}
 
-	for i, flag := range flags {
+	for i, s := range names {
 		if i > 0 {
 			buf.WriteByte('\n')
 		}
 
-		s := info[i]
 		fBuf := bytes.NewBufferString(s)
 
 		if len(s) < maxLen {
 			fBuf.WriteString(createPad(maxLen - len(s)))
 		}
 
-		// Special conditions if the description is on a new line from the flag.
+		// Special conditions if the description is on a new line from the name.
 		if len(s) > maxLen {
 			fBuf.WriteByte('\n')
 			fBuf.WriteString(createPad(maxLen + f.DescPad))
@@ -193,9 +295,7 @@ func (f *Formatter) renderFlags(buf *bytes.Buffer, fs FlagSet) *bytes.Buffer {
 
 		newLineIndent := maxLen + f.DescPad*2
 
-		if len(flag.Description) > 0 {
-			fBuf.WriteString(flag.Description)
-		}
+		fBuf.WriteString(descs[i])
 
 		f.renderWrappedText(buf, fBuf.String(), newLineIndent)
 	}
@@ -285,6 +385,60 @@ func findWrapPos(text string, width int) int {
 	return width
 }
 
+// describe returns the description of the Flag, followed by its default value and environment variable.
+func describe(flag *Flag, fs *FlagSet) string {
+	parts := make([]string, 0, 3)
+
+	if len(flag.Description) > 0 {
+		parts = append(parts, flag.Description)
+	}
+	if len(flag.Default) > 0 {
+		parts = append(parts, "(default: "+flag.Default+")")
+	}
+	if env := fs.EnvVar(flag); len(env) > 0 {
+		parts = append(parts, "[env: "+env+"]")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// longName returns the long flag name to display for the Flag, ie. "[no-]color" for a Negatable flag.
+func longName(flag *Flag) string {
+	if flag.Negatable {
+		return "[" + NegationPrefix + "]" + flag.Long
+	}
+	return flag.Long
+}
+
+// argUsage returns the argument name following the flag name, with its separator,
+// ie. "=ARG", " ARG" if there is no long flag, or "[=ARG]" and "[ARG]" for an optional argument.
+func argUsage(flag *Flag, name string) string {
+	switch {
+	case flag.OptionalArg && len(flag.Long) == 0:
+		return "[" + name + "]"
+	case flag.OptionalArg:
+		return "[" + string(ValueSeparator) + name + "]"
+	case len(flag.Long) == 0:
+		return " " + name
+	}
+	return string(ValueSeparator) + name
+}
+
+// argName returns the argument name to display for the Flag.
+// Flags with choices display the choices, ie. "{json|yaml}",
+// flags with a custom Value and no custom ArgName display the Value type.
+func argName(flag *Flag) string {
+	if len(flag.Choices) > 0 {
+		return "{" + strings.Join(flag.Choices, "|") + "}"
+	}
+	if flag.Value != nil && flag.ArgName == defaultArgName {
+		if typ := flag.Value.Type(); len(typ) > 0 {
+			return strings.ToUpper(typ)
+		}
+	}
+	return flag.ArgName
+}
+
 // createPad returns a string length 'length' of spaces.
 func createPad(length int) string {
 	b := make([]rune, length)
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatter_PrintFlags(t *testing.T) {
+	fs := NewFlagSet()
+	fs.AddNewFlag('a', "all", "show all", false)
+	fp, _ := fs.AddNewFlag('p', "port", "the port", true)
+	fp.EnvVar = "APP_PORT"
+	fp.Default = "80"
+	fs.AddNewValueFlag(0, "level", "", new(levelValue))
+	fs.AddNewChoiceFlag(0, "format", "the format", "json", "text")
+	fc, _ := fs.AddNewFlag(0, "color", "colorize output", false)
+	fc.Negatable = true
+	fw, _ := fs.AddNewFlag('O', "", "optimise", true)
+	fw.OptionalArg = true
+	fh, _ := fs.AddNewFlag(0, "hyperlink", "", true)
+	fh.OptionalArg = true
+	fh.ArgName = "WHEN"
+
+	want := `
+Flags:
+  -a, --all                 show all
+  -O[ARG]                   optimise
+  -p, --port=ARG            the port (default: 80) [env: APP_PORT]
+      --[no-]color          colorize output
+      --format={json|text}  the format
+      --hyperlink[=WHEN]
+      --level=LEVEL
+`
+
+	buf := new(bytes.Buffer)
+	NewFormatter().PrintFlags(buf, *fs)
+	if got := buf.String(); got != want {
+		t.Errorf("Formatter.PrintFlags() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatter_PrintFlags_groups(t *testing.T) {
+	fs := NewFlagSet()
+	fs.AddNewFlag('v', "verbose", "verbose output", false)
+	fp, _ := fs.AddNewFlag('p', "port", "the port", true)
+	fp.Group = "Networking"
+	fd, _ := fs.AddNewFlag(0, "debug", "debug output", false)
+	fd.Group = "Debug"
+	fh, _ := fs.AddNewFlag(0, "host", "the host", true)
+	fh.Group = "Networking"
+
+	want := `
+Flags:
+  -v, --verbose  verbose output
+
+Networking:
+  -p, --port=ARG  the port
+      --host=ARG  the host
+
+Debug:
+      --debug  debug output
+`
+
+	buf := new(bytes.Buffer)
+	NewFormatter().PrintFlags(buf, *fs)
+	if got := buf.String(); got != want {
+		t.Errorf("Formatter.PrintFlags() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatter_Usage(t *testing.T) {
+	fs := NewFlagSet()
+	fs.AddArg(NewVariadicArg("SRC", "the source files", true))
+	fs.AddNewArg("DEST", "the destination", true)
+
+	f := NewFormatter()
+	if got, want := f.Usage("tool", *fs), "tool SRC... DEST"; got != want {
+		t.Errorf("Formatter.Usage() = %v, want %v", got, want)
+	}
+
+	fs.AddNewFlag('f', "force", "", false)
+	if got, want := f.Usage("tool", *fs), "tool [FLAGS] SRC... DEST"; got != want {
+		t.Errorf("Formatter.Usage() = %v, want %v", got, want)
+	}
+
+	want := `
+Arguments:
+  SRC   the source files
+  DEST  the destination
+`
+	buf := new(bytes.Buffer)
+	f.PrintArgs(buf, *fs)
+	if got := buf.String(); got != want {
+		t.Errorf("Formatter.PrintArgs() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// NamingPolicy represents the rules for flag names in a FlagSet.
+type NamingPolicy struct {
+	// ShortDigits allows digits as short flags, ie. -1 or -6.
+	// A declared digit short flag takes precedence over a negative number, ie. -1 but not -2.
+	ShortDigits bool
+	// ShortSymbols are the other characters allowed as short flags, ie. "?" for -?.
+	// ShortPrefix and ValueSeparator are never allowed.
+	ShortSymbols string
+
+	// AllowDigits allows digits in long flags, ie. --http2.
+	AllowDigits bool
+	// Symbols are the other characters allowed in long flags, ie. "-_." for --dry-run and --tls1.3.
+	// A long flag must start with a letter, and can never contain ValueSeparator.
+	Symbols string
+	// CaseSensitive keeps long flags as added and matches them exactly,
+	// otherwise they are lowercased and matched ignoring case.
+	CaseSensitive bool
+	// MinLength is the minimum number of characters in a long flag.
+	MinLength int
+}
+
+// DefaultNamingPolicy is the NamingPolicy used by a FlagSet without one, short flags are letters
+// and long flags are case-insensitive letters only, at least 2 characters long.
+var DefaultNamingPolicy = NamingPolicy{MinLength: 2}
+
+// PermissiveNamingPolicy allows the long flag names common to GNU programs,
+// ie. --dry-run, --log_level, --http2 and --tls1.3, matched ignoring case.
+var PermissiveNamingPolicy = NamingPolicy{AllowDigits: true, Symbols: "-_.", MinLength: 2}
+
+// fold returns the long flag name as it is stored, lowercase unless the policy is CaseSensitive.
+func (n *NamingPolicy) fold(name string) string {
+	if n.CaseSensitive {
+		return name
+	}
+	return strings.ToLower(name)
+}
+
+// hasPrefix returns whether the long flag name starts with the prefix, ignoring case unless CaseSensitive.
+func (n *NamingPolicy) hasPrefix(name string, prefix string) bool {
+	return strings.HasPrefix(n.fold(name), n.fold(prefix))
+}
+
+// validateShort returns the reason the short flag breaks the policy, or an empty string if it is valid.
+func (n *NamingPolicy) validateShort(short rune) string {
+	switch {
+	case unicode.IsLetter(short):
+		return ""
+	case short == ValueSeparator || strings.ContainsRune(ShortPrefix, short):
+	case n.ShortDigits && unicode.IsDigit(short):
+		return ""
+	case strings.ContainsRune(n.ShortSymbols, short):
+		return ""
+	}
+	return fmt.Sprintf("short flag '%v' is not a letter", short)
+}
+
+// validate returns the reason the long flag name breaks the policy, or an empty string if it is valid.
+func (n *NamingPolicy) validate(name string) string {
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r):
+			continue
+		case i == 0:
+			return fmt.Sprintf(`long flag "%v" does not start with a letter`, name)
+		case r == ValueSeparator:
+		case n.AllowDigits && unicode.IsDigit(r):
+			continue
+		case strings.ContainsRune(n.Symbols, r):
+			continue
+		}
+		return fmt.Sprintf(`long flag "%v" contains a non letter '%v'`, name, r)
+	}
+
+	if len([]rune(name)) < n.MinLength {
+		return fmt.Sprintf(`long flag "%v" must be %d or more letters`, name, n.MinLength)
+	}
+	return ""
+}
+
+// naming returns the NamingPolicy of the FlagSet, DefaultNamingPolicy if none is set.
+func (f *FlagSet) naming() *NamingPolicy {
+	if f.Naming != nil {
+		return f.Naming
+	}
+	return &DefaultNamingPolicy
+}
+
+// long returns the Flag with the long flag name, matched as set by the NamingPolicy.
+func (f *FlagSet) long(name string) (*Flag, bool) {
+	flag, ok := f.longs[f.naming().fold(name)]
+	return flag, ok
+}
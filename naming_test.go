@@ -0,0 +1,121 @@
+package cli
+
+import "testing"
+
+func TestFlagSet_AddFlag_naming(t *testing.T) {
+	tests := []struct {
+		name    string
+		naming  *NamingPolicy
+		long    string
+		wantErr bool
+	}{
+		{"default letters", nil, "verbose", false},
+		{"default hyphen", nil, "dry-run", true},
+		{"default digit", nil, "http2", true},
+		{"permissive hyphen", &PermissiveNamingPolicy, "dry-run", false},
+		{"permissive underscore", &PermissiveNamingPolicy, "log_level", false},
+		{"permissive digit", &PermissiveNamingPolicy, "http2", false},
+		{"permissive dot", &PermissiveNamingPolicy, "tls1.3", false},
+		{"permissive leading symbol", &PermissiveNamingPolicy, "-dry", true},
+		{"permissive leading digit", &PermissiveNamingPolicy, "2fa", true},
+		{"value separator", &NamingPolicy{Symbols: "=", MinLength: 2}, "a=b", true},
+		{"min length", &NamingPolicy{MinLength: 4}, "abc", true},
+		{"single letter", &NamingPolicy{MinLength: 1}, "x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlagSet()
+			f.Naming = tt.naming
+			if err := f.AddFlag(NewFlag(0, tt.long, "", false)); (err != nil) != tt.wantErr {
+				t.Errorf("FlagSet.AddFlag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParser_ParseArgs_naming(t *testing.T) {
+	fs := NewFlagSet()
+	fs.Naming = &NamingPolicy{AllowDigits: true, Symbols: "-.", CaseSensitive: true, MinLength: 2}
+	fd, _ := fs.AddNewFlag(0, "dry-run", "", false)
+	fu, _ := fs.AddNewFlag(0, "HTTP2", "", false)
+	ft, _ := fs.AddNewFlag(0, "tls1.3", "", true)
+
+	if fu.Long != "HTTP2" {
+		t.Errorf("Flag.Long = %v, want HTTP2", fu.Long)
+	}
+
+	got, err := NewParser().ParseArgs(fs, []string{"--dry-run", "--HTTP2", "--tls1.3=on"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	for _, flag := range []*Flag{fd, fu, ft} {
+		if _, ok := got.Value(flag); !ok {
+			t.Errorf("CommandLine.Value(%v) ok = false, want true", flag.Long)
+		}
+	}
+
+	if _, err := NewParser().ParseArgs(fs, []string{"--http2"}); err == nil {
+		t.Errorf("Parser.ParseArgs() error = nil, want error for case mismatch")
+	}
+	if got := fs.Matches("HT"); len(got) != 1 || got[0] != "HTTP2" {
+		t.Errorf("FlagSet.Matches() = %v, want [HTTP2]", got)
+	}
+	if got := fs.EnvVar(ft); got != "" {
+		t.Errorf("FlagSet.EnvVar() = %v, want empty", got)
+	}
+	fs.EnvPrefix = "APP_"
+	if got := fs.EnvVar(ft); got != "APP_TLS1_3" {
+		t.Errorf("FlagSet.EnvVar() = %v, want APP_TLS1_3", got)
+	}
+}
+
+func TestParser_ParseArgs_symbolShorts(t *testing.T) {
+	defer unsetenv("POSIXLY_CORRECT")()
+
+	fs := NewFlagSet()
+	fs.Naming = &NamingPolicy{ShortDigits: true, ShortSymbols: "?", MinLength: 2}
+	f6, _ := fs.AddNewFlag('6', "", "", false)
+	fh, _ := fs.AddNewFlag('?', "", "", false)
+	fn, _ := fs.AddNewFlag('n', "", "", true)
+
+	if _, err := NewParser().ParseArgs(fs, []string{"-n", "-6"}); err == nil {
+		t.Errorf("Parser.ParseArgs() error = nil, want missing value for -n followed by declared -6")
+	}
+
+	got, err := NewParser().ParseArgs(fs, []string{"-6?", "-n", "-4", "-2"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	for _, flag := range []*Flag{f6, fh} {
+		if _, ok := got.Value(flag); !ok {
+			t.Errorf("CommandLine.Value(%v) ok = false, want true", flag.Short)
+		}
+	}
+	if val, _ := got.Value(fn); val != "-4" {
+		t.Errorf("CommandLine.Value() = %v, want -4", val)
+	}
+	if args := got.Args(); len(args) != 1 || args[0] != "-2" {
+		t.Errorf("CommandLine.Args() = %v, want [-2]", args)
+	}
+
+	// Numbers starting with a declared digit short flag are still numbers.
+	got, err = NewParser().ParseArgs(fs, []string{"-n", "-60", "-6.5", "-6"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	if val, _ := got.Value(fn); val != "-60" {
+		t.Errorf("CommandLine.Value() = %v, want -60", val)
+	}
+	if args := got.Args(); len(args) != 1 || args[0] != "-6.5" {
+		t.Errorf("CommandLine.Args() = %v, want [-6.5]", args)
+	}
+	if _, ok := got.Value(f6); !ok {
+		t.Errorf("CommandLine.Value(6) ok = false, want true")
+	}
+
+	for _, short := range []rune{'-', '=', '!'} {
+		if err := fs.AddFlag(NewFlag(short, "", "", false)); err == nil {
+			t.Errorf("FlagSet.AddFlag(%q) error = nil, want error", short)
+		}
+	}
+}
@@ -1,39 +1,90 @@
 package cli
 
 import (
-	"fmt"
 	"math"
 	"os"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 const (
 	ShortPrefix    = "-"
 	LongPrefix     = "--"
+	NegationPrefix = "no-"
 	ValueSeparator = '='
 )
 
+// Ordering represents where flags may appear among the positional arguments.
+type Ordering int
+
+const (
+	// Permute allows flags anywhere, as with GNU getopt.
+	// The POSIXLY_CORRECT environment variable selects RequireOrder instead.
+	Permute Ordering = iota
+	// RequireOrder stops parsing flags at the first positional argument, as with POSIX getopt.
+	RequireOrder
+	// StopAfterArgs stops parsing flags after Parser.StopAfter positional arguments,
+	// ie. 1 for "tool exec CMD --its-own-flags".
+	StopAfterArgs
+)
+
 // Parser represents a command line argument parser.
 type Parser struct {
+	// Config is the config used for flags not set by the arguments or environment (nil for none).
+	Config *Config
+	// ConfigFlag is the flag naming a config file, ie. NewConfigFlag() (nil for none).
+	// The config file takes precedence over Config and must be keyed by the flags being parsed.
+	ConfigFlag *Flag
+	// AllowAbbrev allows long flags to be abbreviated to an unambiguous prefix,
+	// ie. --verb for --verbose, as with getopt_long.
+	AllowAbbrev bool
+	// StrictShort disables attached short flag values, so every rune after the prefix is a flag.
+	// By default the rest of a short flag cluster after a flag with an argument is its value,
+	// ie. -ofile.txt, -vofile.txt and -o=file.txt, as with getopt.
+	StrictShort bool
+	// Ordering is where flags may appear among the positional arguments, Permute by default.
+	// Arguments after flags stop being parsed are positional arguments, as after "--".
+	Ordering Ordering
+	// StopAfter is the number of positional arguments after which flags stop being parsed with StopAfterArgs.
+	// With 0 no flags are parsed, except before the name of a child command.
+	StopAfter int
+	// ResponseFiles expands "@path" arguments to the arguments read from the file, as with gcc.
+	// Errors caused by an argument read from a file are wrapped in a *ResponseFileError
+	// with its file and line, and are located by their position in the expanded arguments.
+	ResponseFiles bool
+
 	cmd      *commandLine // the command-line instance
 	flags    *FlagSet     // the flags being parsed against
+	visited  []*FlagSet   // the flags parsed against, including those of parent commands
 	expected []*Flag      // the expected flags
+	command  *Command     // the command being parsed (nil if not parsing commands)
 
 	skipParsing bool   // true if no more flags should be parsed
 	curFlag     *Flag  // the last flag parsed
 	curToken    string // the token currently being parsed
+	curPos      int    // the position of the token currently being parsed
 }
 
 // NewParser returns a new parser.
 func NewParser() *Parser {
 	p := &Parser{
-		cmd:         nil,
-		flags:       nil,
-		expected:    nil,
-		skipParsing: false,
-		curFlag:     nil,
-		curToken:    "",
+		Config:        nil,
+		ConfigFlag:    nil,
+		AllowAbbrev:   false,
+		StrictShort:   false,
+		Ordering:      Permute,
+		StopAfter:     0,
+		ResponseFiles: false,
+		cmd:           nil,
+		flags:         nil,
+		visited:       nil,
+		expected:      nil,
+		command:       nil,
+		skipParsing:   false,
+		curFlag:       nil,
+		curToken:      "",
+		curPos:        0,
 	}
 	return p
 }
@@ -45,37 +96,127 @@ func (p *Parser) Parse(flags *FlagSet) (CommandLine, error) {
 
 // ParseArgs parses the specified slice of string arguments.
 func (p *Parser) ParseArgs(flags *FlagSet, args []string) (CommandLine, error) {
+	p.command = nil
+	return p.parse(flags, args)
+}
+
+// ParseCommand parses the specified slice of string arguments against the command tree of cmd.
+// Leading arguments matching a child command name or alias select that command,
+// the persistent flags of a command are inherited by its descendants.
+func (p *Parser) ParseCommand(cmd *Command, args []string) (CommandLine, error) {
+	flags, err := cmd.flagSet()
+	if err != nil {
+		return nil, err
+	}
+
+	p.command = cmd
+	return p.parse(flags, args)
+}
+
+// Execute parses the specified slice of string arguments against the command tree of cmd,
+// and calls the Run function of the command parsed.
+func (p *Parser) Execute(cmd *Command, args []string) error {
+	c, err := p.ParseCommand(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	cmd = c.Command()
+	if cmd.Run == nil {
+		return &NotRunnableError{Command: cmd}
+	}
+	return cmd.Run(c)
+}
+
+func (p *Parser) parse(flags *FlagSet, args []string) (CommandLine, error) {
 	p.cmd = &commandLine{
-		flags: make([]*Flag, 0),
-		args:  make([]string, 0),
+		flags:   make([]*Flag, 0),
+		args:    make([]string, 0),
+		values:  make(map[*Flag][]string),
+		typed:   make(map[*Flag]interface{}),
+		sources: make(map[*Flag]Source),
+		command: p.command,
 	}
 	p.flags = flags
+	p.visited = []*FlagSet{flags}
 
 	p.expected = make([]*Flag, len(flags.required))
 	copy(p.expected, flags.required)
 
-	p.skipParsing = false
+	p.skipParsing = p.stopsBeforeArgs()
 	p.curFlag = nil
+	flags.resetValues(nil)
+
+	var origins []origin
+	if p.ResponseFiles {
+		var err error
+		if args, origins, err = expandResponseFiles(args); err != nil {
+			return nil, err
+		}
+	}
 
 	if args != nil {
-		for _, token := range args {
+		for i, token := range args {
+			p.curPos = i
+
 			err := p.handleToken(token)
 			if err != nil {
-				return nil, err
+				// Locate errors in the arguments.
+				if e, ok := err.(positioned); ok {
+					e.setPosition(token, i)
+				}
+				return nil, locateResponseFile(err, origins, i)
 			}
 		}
 	}
 
 	if p.curFlag != nil && p.curFlag.HasArg {
-		return nil, fmt.Errorf("missing argument for %v", p.curFlag)
+		err := &MissingValueError{position{p.curToken, p.curPos}, p.curFlag}
+		return nil, locateResponseFile(err, origins, p.curPos)
+	}
+	if len(p.flags.args) > 0 {
+		if err := p.cmd.processArgs(p.flags.args); err != nil {
+			if e, ok := err.(*MissingArgError); ok {
+				// Missing arguments are expected after the last argument.
+				e.setPosition("", len(args))
+			}
+			if e, ok := err.(positioned); ok {
+				return nil, locateResponseFile(err, origins, e.pos())
+			}
+			return nil, err
+		}
+	}
+	if err := p.handleEnv(); err != nil {
+		return nil, err
+	}
+	if err := p.handleConfig(); err != nil {
+		return nil, err
 	}
 	if len(p.expected) > 0 {
-		return nil, fmt.Errorf("missing required flags %v", p.expected)
+		return nil, &MissingRequiredError{Flags: p.expected}
+	}
+	if err := p.handleConstraints(); err != nil {
+		return nil, err
+	}
+	if err := p.handleDefaults(); err != nil {
+		return nil, err
+	}
+	for _, set := range p.visited {
+		set.commitValues()
 	}
 
 	return p.cmd, nil
 }
 
+// locateResponseFile wraps an error caused by the argument at pos in a *ResponseFileError,
+// if the argument was read from a response file.
+func locateResponseFile(err error, origins []origin, pos int) error {
+	if pos >= 0 && pos < len(origins) && len(origins[pos].file) > 0 {
+		return &ResponseFileError{File: origins[pos].file, Line: origins[pos].line, Err: err}
+	}
+	return err
+}
+
 func (p *Parser) handleToken(token string) error {
 	p.curToken = token
 
@@ -83,14 +224,19 @@ func (p *Parser) handleToken(token string) error {
 
 	switch {
 	case p.skipParsing:
-		p.cmd.addArg(token)
+		p.cmd.addArg(token, p.curPos)
 		break
-	case token == LongPrefix || token == ShortPrefix:
+	case token == LongPrefix:
 		p.skipParsing = true
+		p.cmd.dashed = true
+		p.cmd.dash = len(p.cmd.args)
 		break
 	case p.curFlag != nil && p.curFlag.HasArg && p.isArg(token):
 		err = p.cmd.processValue(p.curFlag, token)
 		break
+	case p.isNegativeNumber(token):
+		p.addArg(token)
+		break
 	case strings.HasPrefix(token, LongPrefix):
 		err = p.handleLong(token)
 		break
@@ -113,40 +259,52 @@ func (p *Parser) handleToken(token string) error {
 }
 
 func (p *Parser) handleLong(token string) error {
-	// Long flags are lowercase.
-	long := strings.ToLower(token)
-	long = long[len(LongPrefix):]
+	long := token[len(LongPrefix):]
 
 	i := strings.IndexRune(long, ValueSeparator)
 	if i == -1 {
-		flag, ok := p.flags.longs[long]
-		if !ok {
+		flag, err := p.lookupLong(long)
+		if err != nil {
+			return err
+		}
+		if flag == nil {
+			// Negated flags, ie. --no-color.
+			if flag = p.lookupNegated(long); flag != nil {
+				return p.handleBool(flag, false)
+			}
 			return p.handleUnknown(token)
 		}
 		return p.handleFlag(flag)
 	}
 
 	if len(long) <= i+1 {
-		return fmt.Errorf(`no value found for "%v" after '%v'`, token, ValueSeparator)
+		flag, _ := p.lookupLong(long[:i])
+		return &MissingValueError{noPosition, flag}
 	}
 
+	// Values are kept as is.
 	long, val := long[:i], long[i+1:]
-	flag, ok := p.flags.longs[long]
-	if !ok || !flag.HasArg {
+	flag, err := p.lookupLong(long)
+	if err != nil {
+		return err
+	}
+	if flag != nil && flag.Negatable {
+		set, err := strconv.ParseBool(val)
+		if err != nil {
+			return &InvalidValueError{position: noPosition, Flag: flag, Value: val, Source: SourceArgs}
+		}
+		return p.handleBool(flag, set)
+	}
+	if flag == nil || !flag.HasArg {
 		return p.handleUnknown(token)
 	}
 
-	err := p.handleFlag(flag)
+	err = p.handleFlag(flag)
 	if err != nil {
 		return err
 	}
 
-	// Strip leading and trailing ""
-	if runes := []rune(long); runes[0] == '"' && runes[len(runes)-1] == '"' {
-		long = string(runes[1 : len(runes)-2])
-	}
-
-	err = p.cmd.processValue(p.curFlag, val)
+	err = p.cmd.processValue(flag, val)
 	if err != nil {
 		return err
 	}
@@ -164,8 +322,12 @@ func (p *Parser) handleShort(token string) error {
 	for i, short := range runes {
 		flag, ok := p.flags.shorts[short]
 		if !ok {
+			// A flag earlier in the cluster still needs its value, ie. -ofile.txt with StrictShort.
+			if p.curFlag != nil && p.curFlag.HasArg {
+				return &MissingValueError{noPosition, p.curFlag}
+			}
 			if i > 0 {
-				token = string(runes[i:])
+				token = ShortPrefix + string(runes[i:])
 			}
 			return p.handleUnknown(token)
 		}
@@ -174,61 +336,415 @@ func (p *Parser) handleShort(token string) error {
 		if err != nil {
 			return err
 		}
+
+		if !flag.HasArg || i+1 == len(runes) {
+			continue
+		}
+
+		// The rest of the token is the optional argument, ie. -O2.
+		if flag.OptionalArg {
+			return p.handleAttached(flag, string(runes[i+1:]))
+		}
+		if !p.StrictShort {
+			p.curFlag = nil
+			return p.handleAttached(flag, string(runes[i+1:]))
+		}
 	}
 
 	return nil
 }
 
+// handleAttached handles the value attached to a short flag, ie. "file.txt" for -ofile.txt.
+// A leading '=' is stripped, ie. -o=file.txt, unless StrictShort is set.
+func (p *Parser) handleAttached(flag *Flag, val string) error {
+	if !p.StrictShort && strings.HasPrefix(val, string(ValueSeparator)) {
+		val = val[1:]
+		if len(val) == 0 {
+			return &MissingValueError{noPosition, flag}
+		}
+	}
+	return p.cmd.processValue(flag, val)
+}
+
 func (p *Parser) handleFlag(flag *Flag) error {
+	if flag.Negatable {
+		return p.handleBool(flag, true)
+	}
 	if p.curFlag != nil && p.curFlag.HasArg {
-		return fmt.Errorf("missing argument for %v", p.curFlag)
+		return &MissingValueError{noPosition, p.curFlag}
 	}
 
-	// Remove required flag from expected.
-	if flag.Required {
-		i := -1
-		for j, f := range p.expected {
-			if flag == f {
-				i = j
-				break
-			}
+	p.removeExpected(flag)
+
+	if !flag.Repeatable && p.cmd.Count(flag) > 0 {
+		return &DuplicateFlagError{position: noPosition, Flag: flag}
+	}
+	if err := p.cmd.processFlag(flag, SourceArgs); err != nil {
+		return err
+	}
+
+	// Optional arguments must be attached, so are not taken from the next token.
+	if flag.HasArg && !flag.OptionalArg {
+		p.curFlag = flag
+	} else {
+		p.curFlag = nil
+	}
+
+	return nil
+}
+
+// handleBool handles a Negatable flag set to true or false, the last one parsed wins.
+func (p *Parser) handleBool(flag *Flag, set bool) error {
+	if p.curFlag != nil && p.curFlag.HasArg {
+		return &MissingValueError{noPosition, p.curFlag}
+	}
+
+	p.removeExpected(flag)
+	p.curFlag = nil
+
+	return p.cmd.processBool(flag, set, SourceArgs)
+}
+
+// lookupNegated returns the Negatable Flag for a negated long flag name, ie. "no-color",
+// or nil if there is none.
+func (p *Parser) lookupNegated(name string) *Flag {
+	if !p.flags.naming().hasPrefix(name, NegationPrefix) {
+		return nil
+	}
+
+	flag, _ := p.lookupLong(name[len(NegationPrefix):])
+	if flag == nil || !flag.Negatable {
+		return nil
+	}
+	return flag
+}
+
+// lookupLong returns the Flag for the long flag name, or nil if there is none.
+// If AllowAbbrev is set, an unambiguous prefix of a long flag also matches,
+// and an *AmbiguousFlagError is returned for a prefix of several long flags.
+func (p *Parser) lookupLong(name string) (*Flag, error) {
+	if flag, ok := p.flags.long(name); ok {
+		return flag, nil
+	}
+	// An empty name is a prefix of every long flag, ie. "--=x" or "--no-".
+	if !p.AllowAbbrev || len(name) == 0 {
+		return nil, nil
+	}
+
+	switch m := p.flags.longMatches(name); len(m) {
+	case 0:
+		return nil, nil
+	case 1:
+		return p.flags.longs[m[0]], nil
+	default:
+		for i, long := range m {
+			m[i] = LongPrefix + long
 		}
+		return nil, &AmbiguousFlagError{position: noPosition, Candidates: m}
+	}
+}
 
-		if i > -1 {
-			// Remove flag from expected and clear the pointer to prevent memory leaks.
-			// This removal does not preserve order.
-			p.expected[i] = p.expected[len(p.expected)-1]
-			p.expected[len(p.expected)-1] = nil
-			p.expected = p.expected[:len(p.expected)-1]
+// handleConstraints checks the constraints of the FlagSet against the flags set, before defaults are applied.
+func (p *Parser) handleConstraints() error {
+	// Negatable flags set to false are not enabled, ie. --no-json.
+	isEnabled := func(flag *Flag) bool {
+		if flag.Negatable {
+			enabled, _ := p.cmd.Bool(flag)
+			return enabled
+		}
+		return p.cmd.Source(flag) != SourceNone
+	}
+	for _, c := range p.flags.constraints {
+		if flags, missing := c.check(isEnabled); flags != nil {
+			return &ConstraintError{Constraint: c, Flags: flags, Missing: missing}
 		}
 	}
+	return nil
+}
 
-	for _, f := range p.cmd.flags {
+// removeExpected removes a required flag from expected.
+func (p *Parser) removeExpected(flag *Flag) {
+	if !flag.Required {
+		return
+	}
+
+	i := -1
+	for j, f := range p.expected {
 		if flag == f {
-			return fmt.Errorf("CommandLine already contains %v", flag)
+			i = j
+			break
 		}
 	}
-	p.cmd.flags = append(p.cmd.flags, flag)
 
-	if flag.HasArg {
-		p.curFlag = flag
-	} else {
-		p.curFlag = nil
+	if i > -1 {
+		// Remove flag from expected and clear the pointer to prevent memory leaks.
+		// This removal does not preserve order.
+		p.expected[i] = p.expected[len(p.expected)-1]
+		p.expected[len(p.expected)-1] = nil
+		p.expected = p.expected[:len(p.expected)-1]
+	}
+}
+
+// handleEnv fills the flags not parsed from their environment variables.
+func (p *Parser) handleEnv() error {
+	for _, flag := range p.flags.Flags() {
+		if p.cmd.Count(flag) > 0 {
+			continue
+		}
+
+		name := p.flags.EnvVar(flag)
+		if len(name) == 0 {
+			continue
+		}
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		// Flags without an argument are set by a boolean value.
+		if !flag.HasArg {
+			set, err := strconv.ParseBool(val)
+			if err != nil {
+				return &InvalidValueError{position: noPosition, Flag: flag, Value: val, Source: SourceEnv, EnvVar: name}
+			}
+			if flag.Negatable {
+				if err := p.cmd.processBool(flag, set, SourceEnv); err != nil {
+					return err
+				}
+				p.removeExpected(flag)
+				continue
+			}
+			if !set {
+				continue
+			}
+		}
+
+		err := p.cmd.processFlag(flag, SourceEnv)
+		if err == nil && flag.HasArg {
+			err = p.cmd.processValue(flag, val)
+		}
+		if err != nil {
+			if e, ok := err.(*InvalidValueError); ok {
+				e.EnvVar = name
+			}
+			return err
+		}
+		p.removeExpected(flag)
+	}
+
+	return nil
+}
+
+// handleConfig fills the flags not set from the config file named by the ConfigFlag and the Config.
+func (p *Parser) handleConfig() error {
+	var configs []*Config
+	if p.ConfigFlag != nil {
+		if path, ok := p.cmd.Value(p.ConfigFlag); ok && len(path) > 0 {
+			cfg, err := LoadConfig(path, p.flags)
+			if err != nil {
+				return err
+			}
+			configs = append(configs, cfg)
+		}
+	}
+	if p.Config != nil {
+		configs = append(configs, p.Config)
+	}
+
+	for _, flag := range p.flags.Flags() {
+		if p.cmd.Source(flag) != SourceNone {
+			continue
+		}
+
+		for _, cfg := range configs {
+			vals, ok := cfg.Values(flag)
+			if !ok {
+				continue
+			}
+			if err := p.handleConfigValues(flag, vals); err != nil {
+				return err
+			}
+			break
+		}
 	}
 
 	return nil
 }
 
+func (p *Parser) handleConfigValues(flag *Flag, vals []string) error {
+	// Flags without an argument are set by a boolean value.
+	if !flag.HasArg {
+		for _, val := range vals {
+			set, err := strconv.ParseBool(val)
+			if err != nil {
+				return &InvalidValueError{position: noPosition, Flag: flag, Value: val, Source: SourceConfig}
+			}
+			if flag.Negatable {
+				err = p.cmd.processBool(flag, set, SourceConfig)
+			} else if set {
+				err = p.cmd.processFlag(flag, SourceConfig)
+			} else {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			p.removeExpected(flag)
+		}
+		return nil
+	}
+
+	for _, val := range vals {
+		if err := p.cmd.processFlag(flag, SourceConfig); err != nil {
+			return err
+		}
+		if err := p.cmd.processValue(flag, val); err != nil {
+			return err
+		}
+	}
+	p.removeExpected(flag)
+
+	return nil
+}
+
+// handleDefaults sets the default values of the flags not set.
+// Defaults do not satisfy required flags.
+func (p *Parser) handleDefaults() error {
+	for _, flag := range p.flags.Flags() {
+		if p.cmd.Source(flag) != SourceNone {
+			continue
+		}
+		if err := p.cmd.processDefault(flag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *Parser) handleUnknown(token string) error {
 	if strings.HasPrefix(token, LongPrefix) && len(token) > len(LongPrefix) {
-		return fmt.Errorf(`unrecognised flag "%v"`, token)
+		return &UnknownFlagError{position{token, p.curPos}, p.suggestFlags(token)}
 	}
 	if strings.HasPrefix(token, ShortPrefix) && len(token) > len(ShortPrefix) {
-		return fmt.Errorf(`unrecognised flag "%v"`, token)
+		return &UnknownFlagError{position{token, p.curPos}, p.suggestFlags(token)}
+	}
+
+	// Leading arguments may select a child command.
+	if p.command != nil && len(p.command.commands) > 0 && len(p.cmd.args) == 0 {
+		return p.handleCommand(token)
+	}
+
+	p.addArg(token)
+
+	return nil
+}
+
+// addArg adds a positional argument, and stops parsing flags if the Ordering requires it.
+func (p *Parser) addArg(token string) {
+	p.cmd.addArg(token, p.curPos)
+
+	switch p.ordering() {
+	case RequireOrder:
+		p.skipParsing = true
+	case StopAfterArgs:
+		p.skipParsing = len(p.cmd.args) >= p.StopAfter
+	}
+}
+
+// stopsBeforeArgs reports whether StopAfterArgs stops parsing flags before any positional argument,
+// which is once no child command can be selected.
+func (p *Parser) stopsBeforeArgs() bool {
+	if p.ordering() != StopAfterArgs || p.StopAfter > 0 {
+		return false
+	}
+	return p.command == nil || len(p.command.commands) == 0
+}
+
+// ordering returns the Ordering in effect, RequireOrder for Permute if POSIXLY_CORRECT is set.
+func (p *Parser) ordering() Ordering {
+	if p.Ordering == Permute {
+		if _, ok := os.LookupEnv("POSIXLY_CORRECT"); ok {
+			return RequireOrder
+		}
+	}
+	return p.Ordering
+}
+
+// suggestFlags returns the flags similar to the unknown flag token, closest first.
+func (p *Parser) suggestFlags(token string) []string {
+	name := strings.TrimPrefix(strings.TrimPrefix(token, ShortPrefix), ShortPrefix)
+
+	// Strip trailing =... (if it exists).
+	if i := strings.IndexRune(name, ValueSeparator); i != -1 {
+		name = name[:i]
+	}
+
+	// A short flag with the wrong case, ie. -V for -v.
+	if runes := []rune(name); len(runes) == 1 {
+		var ret []string
+		for _, r := range []rune{unicode.ToLower(runes[0]), unicode.ToUpper(runes[0])} {
+			if _, ok := p.flags.shorts[r]; ok && r != runes[0] {
+				ret = append(ret, ShortPrefix+string(r))
+			}
+		}
+		return ret
 	}
 
-	p.cmd.addArg(token)
-	//p.skipParsing = true
+	longs := make([]string, 0, len(p.flags.longs))
+	for long := range p.flags.longs {
+		longs = append(longs, long)
+	}
+
+	ret := suggest(p.flags.naming().fold(name), longs)
+	for i, long := range ret {
+		ret[i] = LongPrefix + long
+	}
+	return ret
+}
+
+func (p *Parser) handleCommand(token string) error {
+	cmd, ok := p.command.Lookup(token)
+	if !ok {
+		// Runnable commands accept arguments.
+		if p.command.Run != nil {
+			p.addArg(token)
+			return nil
+		}
+
+		var names []string
+		for _, c := range p.command.commands {
+			names = append(names, c.names()...)
+		}
+		return &UnknownCommandError{
+			position:    position{token, p.curPos},
+			Command:     p.command,
+			Suggestions: suggest(token, names),
+		}
+	}
+
+	flags, err := cmd.flagSet()
+	if err != nil {
+		return err
+	}
+
+	// Expect the required flags of the command that have not been parsed,
+	// required flags of the parent command not inherited are no longer expected.
+	expected := make([]*Flag, 0, len(flags.required))
+	for _, flag := range flags.required {
+		if p.cmd.Count(flag) == 0 {
+			expected = append(expected, flag)
+		}
+	}
+	p.expected = expected
+
+	// Keep the values parsed for inherited flags.
+	flags.resetValues(p.flags)
+
+	p.command = cmd
+	p.flags = flags
+	p.visited = append(p.visited, flags)
+	p.cmd.command = cmd
+	p.skipParsing = p.stopsBeforeArgs()
 
 	return nil
 }
@@ -270,9 +786,6 @@ func (p *Parser) isLong(token string) bool {
 		return false
 	}
 
-	// Flags are lowercase.
-	token = strings.ToLower(token)
-
 	// Strip leading prefix.
 	token = token[len(LongPrefix):]
 
@@ -282,12 +795,31 @@ func (p *Parser) isLong(token string) bool {
 		token = token[:i]
 	}
 
-	// Check if token is a long option.
-	_, ok := p.flags.longs[token]
-	return ok
+	// Check if token is a long option, or an abbreviation of one.
+	if _, ok := p.flags.long(token); ok {
+		return true
+	}
+	if p.lookupNegated(token) != nil {
+		return true
+	}
+	return p.AllowAbbrev && len(token) > 0 && len(p.flags.longMatches(token)) > 0
 }
 
 func (p *Parser) isNegativeNumber(token string) bool {
+	// A cluster of declared digit short flags is not a number, ie. -1 but not -10 with only -1 declared.
+	if p.flags != nil && len(token) > len(ShortPrefix) && strings.HasPrefix(token, ShortPrefix) {
+		shorts := true
+		for _, r := range token[len(ShortPrefix):] {
+			if _, ok := p.flags.shorts[r]; !ok {
+				shorts = false
+				break
+			}
+		}
+		if shorts {
+			return false
+		}
+	}
+
 	n, err := strconv.ParseFloat(token, 64)
 	return err == nil && math.Signbit(n)
 }
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"os"
 	"reflect"
 	"testing"
 )
@@ -20,6 +21,10 @@ func TestNewParser(t *testing.T) {
 }
 
 func TestParser_isNegativeNumber(t *testing.T) {
+	digits := NewFlagSet()
+	digits.Naming = &NamingPolicy{ShortDigits: true, MinLength: 2}
+	digits.AddNewFlag('1', "", "", false)
+
 	type fields struct {
 		cmd         *commandLine
 		flags       *FlagSet
@@ -43,6 +48,30 @@ func TestParser_isNegativeNumber(t *testing.T) {
 			args{"-5"},
 			true,
 		},
+		{
+			"digit short flag",
+			fields{nil, digits, nil, false, nil, ""},
+			args{"-1"},
+			false,
+		},
+		{
+			"number starting with digit short flag",
+			fields{nil, digits, nil, false, nil, ""},
+			args{"-10"},
+			true,
+		},
+		{
+			"decimal starting with digit short flag",
+			fields{nil, digits, nil, false, nil, ""},
+			args{"-1.5"},
+			true,
+		},
+		{
+			"negative number with digit short flags",
+			fields{nil, digits, nil, false, nil, ""},
+			args{"-5"},
+			true,
+		},
 		{
 			"positive number",
 			fields{nil, nil, nil, false, nil, ""},
@@ -78,3 +107,590 @@ func TestParser_isNegativeNumber(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_ParseArgs(t *testing.T) {
+	fs := NewFlagSet()
+	fa, _ := fs.AddNewFlag('a', "all", "", false)
+	fp, _ := fs.AddNewFlag('p', "port", "", true)
+	fp.Type = TypeInt
+	fn, _ := fs.AddNewFlag('n', "name", "", true)
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantArgs []string
+		wantVals map[*Flag]string
+		wantErr  bool
+	}{
+		{
+			"flags and args",
+			[]string{"-a", "--port", "80", "file"},
+			[]string{"file"},
+			map[*Flag]string{fa: "", fp: "80"},
+			false,
+		},
+		{
+			"long value keeps case",
+			[]string{"--NAME=Foo"},
+			[]string{},
+			map[*Flag]string{fn: "Foo"},
+			false,
+		},
+		{
+			"invalid int",
+			[]string{"-p", "eighty"},
+			nil,
+			nil,
+			true,
+		},
+		{
+			"missing argument",
+			[]string{"--port"},
+			nil,
+			nil,
+			true,
+		},
+		{
+			"duplicate flag",
+			[]string{"-a", "--all"},
+			nil,
+			nil,
+			true,
+		},
+		{
+			"unknown flag",
+			[]string{"--none"},
+			nil,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewParser().ParseArgs(fs, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.ParseArgs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Args(), tt.wantArgs) {
+				t.Errorf("Parser.ParseArgs() args = %v, want %v", got.Args(), tt.wantArgs)
+			}
+			for flag, want := range tt.wantVals {
+				if val, ok := got.Value(flag); !ok || val != want {
+					t.Errorf("Parser.ParseArgs() value = %v, %v, want %v", val, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParser_ParseArgs_repeatable(t *testing.T) {
+	fs := NewFlagSet()
+	fi, _ := fs.AddNewFlag('I', "include", "", true)
+	fi.Repeatable = true
+	fv, _ := fs.AddNewFlag('v', "verbose", "", false)
+	fv.Repeatable = true
+
+	got, err := NewParser().ParseArgs(fs, []string{"-I", "a", "-vvv", "--include=b", "-I", "c"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+
+	want := []string{"a", "b", "c"}
+	if vals := got.Values(fi); !reflect.DeepEqual(vals, want) {
+		t.Errorf("CommandLine.Values() = %v, want %v", vals, want)
+	}
+	if n := got.Count(fv); n != 3 {
+		t.Errorf("CommandLine.Count() = %v, want %v", n, 3)
+	}
+}
+
+func TestParser_ParseArgs_env(t *testing.T) {
+	fs := NewFlagSet()
+	fs.EnvPrefix = "CLI_TEST_"
+	fp, _ := fs.AddNewRequiredFlag('p', "port", "", true)
+	fp.Type = TypeInt
+	fh, _ := fs.AddNewFlag(0, "host", "", true)
+	fh.EnvVar = "CLI_TEST_HOSTNAME"
+	fd, _ := fs.AddNewFlag(0, "dryrun", "", false)
+	fq, _ := fs.AddNewFlag('q', "", "", false)
+
+	os.Setenv("CLI_TEST_PORT", "8080")
+	os.Setenv("CLI_TEST_HOSTNAME", "example.com")
+	os.Setenv("CLI_TEST_DRYRUN", "false")
+	defer os.Unsetenv("CLI_TEST_PORT")
+	defer os.Unsetenv("CLI_TEST_HOSTNAME")
+	defer os.Unsetenv("CLI_TEST_DRYRUN")
+
+	got, err := NewParser().ParseArgs(fs, []string{"--host", "localhost"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	if n, ok := got.Int(fp); n != 8080 || !ok {
+		t.Errorf("CommandLine.Int() = %v, %v, want 8080, true", n, ok)
+	}
+	if val, _ := got.Value(fh); val != "localhost" {
+		t.Errorf("CommandLine.Value() = %v, want %v", val, "localhost")
+	}
+	if _, ok := got.Value(fd); ok {
+		t.Errorf("CommandLine.Value() ok = true, want false for false environment variable")
+	}
+	if _, ok := got.Value(fq); ok {
+		t.Errorf("CommandLine.Value() ok = true, want false for short flag")
+	}
+
+	os.Setenv("CLI_TEST_PORT", "eighty")
+	if _, err := NewParser().ParseArgs(fs, nil); err == nil {
+		t.Errorf("Parser.ParseArgs() error = %v, wantErr true", err)
+	}
+
+	os.Unsetenv("CLI_TEST_PORT")
+	if _, err := NewParser().ParseArgs(fs, nil); err == nil {
+		t.Errorf("Parser.ParseArgs() error = %v, wantErr true", err)
+	}
+}
+
+func TestParser_ParseArgs_default(t *testing.T) {
+	fs := NewFlagSet()
+	fp, _ := fs.AddNewFlag('p', "port", "", true)
+	fp.Type = TypeInt
+	fp.Default = "80"
+	fh, _ := fs.AddNewFlag(0, "host", "", true)
+	fh.Default = "localhost"
+	fh.EnvVar = "CLI_TEST_HOST"
+	fc, _ := fs.AddNewFlag('c', "color", "", false)
+	fc.Default = "true"
+	fn, _ := fs.AddNewFlag('n', "name", "", true)
+
+	os.Setenv("CLI_TEST_HOST", "example.com")
+	defer os.Unsetenv("CLI_TEST_HOST")
+
+	got, err := NewParser().ParseArgs(fs, []string{"-p", "8080"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		flag       *Flag
+		wantValue  string
+		wantOk     bool
+		wantSource Source
+	}{
+		{"args", fp, "8080", true, SourceArgs},
+		{"env", fh, "example.com", true, SourceEnv},
+		{"default no argument", fc, "", true, SourceDefault},
+		{"none", fn, "", false, SourceNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if val, ok := got.Value(tt.flag); val != tt.wantValue || ok != tt.wantOk {
+				t.Errorf("CommandLine.Value() = %v, %v, want %v, %v", val, ok, tt.wantValue, tt.wantOk)
+			}
+			if src := got.Source(tt.flag); src != tt.wantSource {
+				t.Errorf("CommandLine.Source() = %v, want %v", src, tt.wantSource)
+			}
+		})
+	}
+
+	os.Unsetenv("CLI_TEST_HOST")
+	got, _ = NewParser().ParseArgs(fs, nil)
+	if n, ok := got.Int(fp); n != 80 || !ok {
+		t.Errorf("CommandLine.Int() = %v, %v, want 80, true", n, ok)
+	}
+	if n := got.Count(fp); n != 0 {
+		t.Errorf("CommandLine.Count() = %v, want 0 for default", n)
+	}
+	if src := got.Source(fh); src != SourceDefault {
+		t.Errorf("CommandLine.Source() = %v, want %v", src, SourceDefault)
+	}
+
+	fp.Default = "eighty"
+	if _, err := NewParser().ParseArgs(fs, nil); err == nil {
+		t.Errorf("Parser.ParseArgs() error = %v, wantErr true for invalid default", err)
+	}
+}
+
+func TestParser_ParseArgs_abbrev(t *testing.T) {
+	fs := NewFlagSet()
+	fv, _ := fs.AddNewFlag(0, "verbose", "", false)
+	fs.AddNewFlag(0, "version", "", false)
+	fo, _ := fs.AddNewFlag(0, "output", "", true)
+
+	p := NewParser()
+	p.AllowAbbrev = true
+
+	got, err := p.ParseArgs(fs, []string{"--verb", "--out=x"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	if _, ok := got.Value(fv); !ok {
+		t.Errorf("CommandLine.Value() ok = false, want true for abbreviation")
+	}
+	if val, _ := got.Value(fo); val != "x" {
+		t.Errorf("CommandLine.Value() = %v, want x", val)
+	}
+
+	_, err = p.ParseArgs(fs, []string{"--ver"})
+	want := `ambiguous flag "--ver", could be one of --verbose, --version`
+	if err == nil || err.Error() != want {
+		t.Errorf("Parser.ParseArgs() error = %v, want %v", err, want)
+	}
+
+	if _, err = NewParser().ParseArgs(fs, []string{"--verb"}); err == nil {
+		t.Errorf("Parser.ParseArgs() error = nil, want error without AllowAbbrev")
+	}
+
+	// A one letter prefix matches long flags only, not the short flag of the same letter.
+	fs = NewFlagSet()
+	fv, _ = fs.AddNewFlag('v', "verbose", "", false)
+	got, err = p.ParseArgs(fs, []string{"--v"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	if _, ok := got.Value(fv); !ok {
+		t.Errorf("CommandLine.Value() ok = false, want true for one letter abbreviation")
+	}
+
+	fs.AddNewFlag(0, "version", "", false)
+	_, err = p.ParseArgs(fs, []string{"--v"})
+	if _, ok := err.(*AmbiguousFlagError); !ok {
+		t.Errorf("Parser.ParseArgs() error = %v, want *AmbiguousFlagError", err)
+	}
+	// An empty name abbreviates no flag.
+	fs = NewFlagSet()
+	fs.AddNewFlag(0, "output", "", true)
+	_, err = p.ParseArgs(fs, []string{"--=x"})
+	if _, ok := err.(*UnknownFlagError); !ok {
+		t.Errorf("Parser.ParseArgs() error = %v, want *UnknownFlagError for --=x", err)
+	}
+
+	fs = NewFlagSet()
+	fc := NewFlag(0, "color", "", false)
+	fc.Negatable = true
+	fs.AddFlag(fc)
+	_, err = p.ParseArgs(fs, []string{"--no-"})
+	if _, ok := err.(*UnknownFlagError); !ok {
+		t.Errorf("Parser.ParseArgs() error = %v, want *UnknownFlagError for --no-", err)
+	}
+}
+
+func TestParser_ParseArgs_choices(t *testing.T) {
+	tests := []struct {
+		name    string
+		fold    bool
+		prefix  bool
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{"exact", false, false, "--format=json", "json", false},
+		{"unknown", false, false, "--format=xml", "", true},
+		{"case", false, false, "--format=JSON", "", true},
+		{"fold", true, false, "--format=JSON", "json", false},
+		{"prefix", false, true, "--format=ta", "table", false},
+		{"ambiguous prefix", false, true, "--format=t", "", true},
+		{"fold prefix", true, true, "--format=Y", "yaml", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFlagSet()
+			ff, _ := fs.AddNewChoiceFlag('f', "format", "", "json", "yaml", "table", "text")
+			ff.FoldChoices = tt.fold
+			ff.PrefixChoice = tt.prefix
+
+			got, err := NewParser().ParseArgs(fs, []string{tt.arg})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parser.ParseArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if val, _ := got.Value(ff); val != tt.want {
+				t.Errorf("CommandLine.Value() = %v, want %v", val, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseArgs_negatable(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    bool
+		wantOk  bool
+		wantErr bool
+	}{
+		{"absent", nil, false, false, false},
+		{"set", []string{"--color"}, true, true, false},
+		{"negated", []string{"--no-color"}, false, true, false},
+		{"last wins", []string{"--color", "--no-color"}, false, true, false},
+		{"explicit true", []string{"--color=true"}, true, true, false},
+		{"explicit false", []string{"--color=false"}, false, true, false},
+		{"invalid", []string{"--color=maybe"}, false, false, true},
+		{"negated value", []string{"--no-color=true"}, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFlagSet()
+			fc, _ := fs.AddNewFlag('c', "color", "", false)
+			fc.Negatable = true
+
+			got, err := NewParser().ParseArgs(fs, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parser.ParseArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if val, ok := got.Bool(fc); val != tt.want || ok != tt.wantOk {
+				t.Errorf("CommandLine.Bool() = %v, %v, want %v, %v", val, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	fs := NewFlagSet()
+	if err := fs.AddFlag(&Flag{Short: 'n', Negatable: true}); err == nil {
+		t.Errorf("FlagSet.AddFlag() error = nil, want error for negatable short flag")
+	}
+}
+
+func TestParser_ParseArgs_optionalArg(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		want     string
+		wantArgs []string
+	}{
+		{"long without value", []string{"--color", "file"}, "", []string{"file"}},
+		{"long with value", []string{"--color=always", "file"}, "always", []string{"file"}},
+		{"short without value", []string{"-O", "2"}, "", []string{"2"}},
+		{"short with value", []string{"-O2"}, "2", []string{}},
+		{"concatenated", []string{"-vO3"}, "3", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFlagSet()
+			fs.AddNewFlag('v', "", "", false)
+			fc, _ := fs.AddNewFlag('O', "color", "", true)
+			fc.OptionalArg = true
+
+			got, err := NewParser().ParseArgs(fs, tt.args)
+			if err != nil {
+				t.Fatalf("Parser.ParseArgs() error = %v", err)
+			}
+			if val, ok := got.Value(fc); val != tt.want || !ok {
+				t.Errorf("CommandLine.Value() = %v, %v, want %v, true", val, ok, tt.want)
+			}
+			if args := got.Args(); !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("CommandLine.Args() = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParser_ParseArgs_attachedShort(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		args    []string
+		want    string
+		wantV   bool
+		wantErr bool
+	}{
+		{"separate", false, []string{"-o", "file.txt"}, "file.txt", false, false},
+		{"attached", false, []string{"-ofile.txt"}, "file.txt", false, false},
+		{"attached separator", false, []string{"-o=file.txt"}, "file.txt", false, false},
+		{"cluster", false, []string{"-vofile.txt"}, "file.txt", true, false},
+		{"cluster value looks like flag", false, []string{"-ov"}, "v", false, false},
+		{"empty after separator", false, []string{"-o="}, "", false, true},
+		{"strict separate", true, []string{"-vo", "file.txt"}, "file.txt", true, false},
+		{"strict attached", true, []string{"-ofile.txt"}, "", false, true},
+		{"strict attached then argument", true, []string{"-ofile.txt", "x"}, "", false, true},
+		{"unknown in cluster", false, []string{"-vz"}, "", false, true},
+		{"strict unknown in cluster", true, []string{"-vz"}, "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFlagSet()
+			fv, _ := fs.AddNewFlag('v', "", "", false)
+			fo, _ := fs.AddNewFlag('o', "", "", true)
+
+			p := NewParser()
+			p.StrictShort = tt.strict
+
+			got, err := p.ParseArgs(fs, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parser.ParseArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if val, _ := got.Value(fo); val != tt.want {
+				t.Errorf("CommandLine.Value() = %v, want %v", val, tt.want)
+			}
+			if _, ok := got.Value(fv); ok != tt.wantV {
+				t.Errorf("CommandLine.Value() ok = %v, want %v", ok, tt.wantV)
+			}
+		})
+	}
+}
+
+// unsetenv unsets the environment variable and returns a function restoring it,
+// ie. POSIXLY_CORRECT which selects RequireOrder instead of Permute.
+func unsetenv(key string) func() {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return func() {}
+	}
+	os.Unsetenv(key)
+	return func() { os.Setenv(key, val) }
+}
+
+func TestParser_ParseArgs_ordering(t *testing.T) {
+	tests := []struct {
+		name      string
+		ordering  Ordering
+		stopAfter int
+		args      []string
+		wantV     bool
+		wantArgs  []string
+	}{
+		{"permute", Permute, 0, []string{"a", "-v", "b"}, true, []string{"a", "b"}},
+		{"require order", RequireOrder, 0, []string{"a", "-v", "b"}, false, []string{"a", "-v", "b"}},
+		{"require order flags first", RequireOrder, 0, []string{"-v", "a", "--x"}, true, []string{"a", "--x"}},
+		{"stop after 2", StopAfterArgs, 2, []string{"a", "-v", "b", "-v"}, true, []string{"a", "b", "-v"}},
+		{"stop after 0", StopAfterArgs, 0, []string{"-v", "a"}, false, []string{"-v", "a"}},
+	}
+
+	defer unsetenv("POSIXLY_CORRECT")()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFlagSet()
+			fv, _ := fs.AddNewFlag('v', "", "", false)
+
+			p := NewParser()
+			p.Ordering = tt.ordering
+			p.StopAfter = tt.stopAfter
+
+			got, err := p.ParseArgs(fs, tt.args)
+			if err != nil {
+				t.Fatalf("Parser.ParseArgs() error = %v", err)
+			}
+			if _, ok := got.Value(fv); ok != tt.wantV {
+				t.Errorf("CommandLine.Value() ok = %v, want %v", ok, tt.wantV)
+			}
+			if args := got.Args(); !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("CommandLine.Args() = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParser_ParseCommand_stopAfter(t *testing.T) {
+	root := NewCommand("tool", "", nil)
+	exec := NewCommand("exec", "", func(CommandLine) error { return nil })
+	exec.Flags = NewFlagSet()
+	fd, _ := exec.Flags.AddNewFlag(0, "dry", "", false)
+	root.AddCommand(exec)
+
+	p := NewParser()
+	p.Ordering = StopAfterArgs
+	p.StopAfter = 1
+
+	got, err := p.ParseCommand(root, []string{"exec", "--dry", "ls", "--all", "-l"})
+	if err != nil {
+		t.Fatalf("Parser.ParseCommand() error = %v", err)
+	}
+	if _, ok := got.Value(fd); !ok {
+		t.Errorf("CommandLine.Value() ok = false, want true")
+	}
+	if args, want := got.Args(), []string{"ls", "--all", "-l"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("CommandLine.Args() = %v, want %v", args, want)
+	}
+	// Flags stop being parsed once the command is selected.
+	p.StopAfter = 0
+	got, err = p.ParseCommand(root, []string{"exec", "--dry", "ls"})
+	if err != nil {
+		t.Fatalf("Parser.ParseCommand() error = %v", err)
+	}
+	if args, want := got.Args(), []string{"--dry", "ls"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("CommandLine.Args() = %v, want %v", args, want)
+	}
+}
+
+func TestParser_ParseArgs_dash(t *testing.T) {
+	defer unsetenv("POSIXLY_CORRECT")()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantBefore []string
+		wantAfter  []string
+	}{
+		{"no dash", []string{"a", "-v", "b"}, []string{"a", "b"}, nil},
+		{"dash", []string{"a", "--", "-v", "--", "b"}, []string{"a"}, []string{"-v", "--", "b"}},
+		{"trailing dash", []string{"a", "--"}, []string{"a"}, []string{}},
+		{"stdin", []string{"-", "-v", "b"}, []string{"-", "b"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFlagSet()
+			fs.AddNewFlag('v', "", "", false)
+
+			got, err := NewParser().ParseArgs(fs, tt.args)
+			if err != nil {
+				t.Fatalf("Parser.ParseArgs() error = %v", err)
+			}
+			if before := got.ArgsBeforeDash(); !reflect.DeepEqual(before, tt.wantBefore) {
+				t.Errorf("CommandLine.ArgsBeforeDash() = %v, want %v", before, tt.wantBefore)
+			}
+			if after := got.ArgsAfterDash(); !reflect.DeepEqual(after, tt.wantAfter) {
+				t.Errorf("CommandLine.ArgsAfterDash() = %#v, want %#v", after, tt.wantAfter)
+			}
+		})
+	}
+
+	// Appending to the arguments before "--" keeps those after it.
+	got, err := NewParser().ParseArgs(NewFlagSet(), []string{"a", "--", "b", "c"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	_ = append(got.ArgsBeforeDash(), "x")
+	if after, want := got.ArgsAfterDash(), []string{"b", "c"}; !reflect.DeepEqual(after, want) {
+		t.Errorf("CommandLine.ArgsAfterDash() = %v, want %v", after, want)
+	}
+
+	fs := NewFlagSet()
+	fo, _ := fs.AddNewFlag('o', "", "", true)
+	got, err = NewParser().ParseArgs(fs, []string{"-o", "-"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	if val, _ := got.Value(fo); val != "-" {
+		t.Errorf("CommandLine.Value() = %v, want -", val)
+	}
+}
+
+func TestParser_ParseArgs_shortClusterErrors(t *testing.T) {
+	fs := NewFlagSet()
+	fs.AddNewFlag('v', "", "", false)
+	fs.AddNewFlag('o', "", "", true)
+
+	p := NewParser()
+	p.StrictShort = true
+
+	_, err := p.ParseArgs(fs, []string{"-ofile.txt", "x"})
+	if _, ok := err.(*MissingValueError); !ok {
+		t.Errorf("Parser.ParseArgs() error = %v, want *MissingValueError", err)
+	}
+
+	_, err = p.ParseArgs(fs, []string{"-vz"})
+	if e, ok := err.(*UnknownFlagError); !ok || e.Token != "-z" {
+		t.Errorf("Parser.ParseArgs() error = %v, want *UnknownFlagError for -z", err)
+	}
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// ResponseFilePrefix is the prefix of an argument naming a response file, ie. "@args.txt".
+const ResponseFilePrefix = "@"
+
+// origin is the response file and line an argument was read from.
+type origin struct {
+	file string // the response file (empty string for the command line)
+	line int    // the line of the argument in the file
+}
+
+// responseExpander expands response file arguments into the arguments read from the files.
+type responseExpander struct {
+	args    []string
+	origins []origin
+	files   []string // the absolute paths of the files being read, to detect cycles
+	dashed  bool     // true once "--" was expanded, after which arguments are kept as is
+}
+
+// expandResponseFiles returns the arguments with each "@path" argument replaced by the arguments
+// read from the file, and where each argument came from.
+// Files are read recursively, relative paths in a file are relative to the file.
+// Arguments after "--" are not expanded.
+func expandResponseFiles(args []string) ([]string, []origin, error) {
+	e := &responseExpander{
+		args:    make([]string, 0, len(args)),
+		origins: make([]origin, 0, len(args)),
+	}
+	for _, arg := range args {
+		if err := e.add(arg, origin{}, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	return e.args, e.origins, nil
+}
+
+func (e *responseExpander) add(arg string, from origin, dir string) error {
+	if !e.dashed && len(arg) > len(ResponseFilePrefix) && strings.HasPrefix(arg, ResponseFilePrefix) {
+		return e.include(arg[len(ResponseFilePrefix):], from, dir)
+	}
+	if arg == LongPrefix {
+		e.dashed = true
+	}
+
+	e.args = append(e.args, arg)
+	e.origins = append(e.origins, from)
+	return nil
+}
+
+func (e *responseExpander) include(path string, from origin, dir string) error {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return &ResponseFileError{File: from.file, Line: from.line, Err: err}
+	}
+	for _, file := range e.files {
+		if file == abs {
+			return &ResponseFileError{File: from.file, Line: from.line, Err: fmt.Errorf("response file %v includes itself", path)}
+		}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return &ResponseFileError{File: from.file, Line: from.line, Err: err}
+	}
+	words, lines, err := splitResponseFile(string(data))
+	if err != nil {
+		return &ResponseFileError{File: path, Line: lines[0], Err: err}
+	}
+
+	e.files = append(e.files, abs)
+	for i, word := range words {
+		if err := e.add(word, origin{path, lines[i]}, filepath.Dir(path)); err != nil {
+			return err
+		}
+	}
+	e.files = e.files[:len(e.files)-1]
+
+	return nil
+}
+
+// splitResponseFile splits the text of a response file into arguments, with the line each starts on.
+// Arguments are separated by whitespace, and quoted as in a POSIX shell:
+// single quotes are literal, double quotes and backslashes escape characters.
+// A '#' starting an argument comments out the rest of the line.
+// On error the line of the invalid argument is returned.
+func splitResponseFile(text string) ([]string, []int, error) {
+	var words []string
+	var lines []int
+
+	buf := new(bytes.Buffer)
+	inWord := false
+	line, start := 1, 1
+
+	// begin starts a word on the current line, unless already in one.
+	begin := func() {
+		if !inWord {
+			inWord, start = true, line
+		}
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			if inWord {
+				words, lines = append(words, buf.String()), append(lines, start)
+				buf.Reset()
+				inWord = false
+			}
+			if r == '\n' {
+				line++
+			}
+			continue
+		case r == '#' && !inWord:
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			i-- // the newline is counted by the next iteration
+			continue
+		}
+
+		switch r {
+		case '\'':
+			begin()
+			j := i + 1
+			for j < len(runes) && runes[j] != '\'' {
+				j++
+			}
+			if j == len(runes) {
+				return nil, []int{start}, errors.New("unterminated single quote")
+			}
+			buf.WriteString(string(runes[i+1 : j]))
+			line += strings.Count(string(runes[i+1:j]), "\n")
+			i = j
+		case '"':
+			begin()
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) && strings.ContainsRune(`"\$`+"`\n", runes[j+1]) {
+					j++
+					if runes[j] == '\n' {
+						// An escaped newline continues the line.
+						line++
+						continue
+					}
+				} else if runes[j] == '\n' {
+					line++
+				}
+				buf.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, []int{start}, errors.New("unterminated double quote")
+			}
+			i = j
+		case '\\':
+			if i+1 == len(runes) {
+				return nil, []int{line}, errors.New("trailing backslash")
+			}
+			i++
+			if runes[i] == '\n' {
+				// An escaped newline continues the line.
+				line++
+				continue
+			}
+			begin()
+			buf.WriteRune(runes[i])
+		default:
+			begin()
+			buf.WriteRune(r)
+		}
+	}
+
+	if inWord {
+		words, lines = append(words, buf.String()), append(lines, start)
+	}
+	return words, lines, nil
+}
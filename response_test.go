@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_splitResponseFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		want      []string
+		wantLines []int
+		wantErr   bool
+	}{
+		{"words", "-v --out=a.txt\n\tb  c\n", []string{"-v", "--out=a.txt", "b", "c"}, []int{1, 1, 2, 2}, false},
+		{"comments", "# flags\n-v # verbose\na#b\n", []string{"-v", "a#b"}, []int{2, 3}, false},
+		{"single quotes", `'a b' 'c\d'`, []string{"a b", `c\d`}, []int{1, 1}, false},
+		{"double quotes", `"a \"b\" \n" x"y"z`, []string{`a "b" \n`, "xyz"}, []int{1, 1}, false},
+		{"escapes", `a\ b \#c`, []string{"a b", "#c"}, []int{1, 1}, false},
+		{"multiline quote", "'a\nb' c", []string{"a\nb", "c"}, []int{1, 2}, false},
+		{"empty quotes", `'' ""`, []string{"", ""}, []int{1, 1}, false},
+		{"escaped newlines", "a \\\n b\\\nc", []string{"a", "bc"}, []int{1, 2}, false},
+		{"unterminated", "a\n'b", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, lines, err := splitResponseFile(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitResponseFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if lines[0] != 2 {
+					t.Errorf("splitResponseFile() line = %v, want 2", lines[0])
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitResponseFile() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("splitResponseFile() lines = %v, want %v", lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestParser_ParseArgs_responseFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"args.rsp":   "-v\n@nested.rsp\n",
+		"nested.rsp": "# nested\n--out 'a b.txt'\n",
+		"bad.rsp":    "-v\n--unknown\n",
+		"extra.rsp":  "a\nb c\n",
+		"cycle.rsp":  "@cycle2.rsp\n",
+		"cycle2.rsp": "@cycle.rsp\n",
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs := NewFlagSet()
+	fv, _ := fs.AddNewFlag('v', "", "", false)
+	fo, _ := fs.AddNewFlag(0, "out", "", true)
+
+	defer unsetenv("POSIXLY_CORRECT")()
+
+	p := NewParser()
+	p.ResponseFiles = true
+
+	got, err := p.ParseArgs(fs, []string{"@" + filepath.Join(dir, "args.rsp"), "x", "--", "@y"})
+	if err != nil {
+		t.Fatalf("Parser.ParseArgs() error = %v", err)
+	}
+	if _, ok := got.Value(fv); !ok {
+		t.Errorf("CommandLine.Value() ok = false, want true")
+	}
+	if val, _ := got.Value(fo); val != "a b.txt" {
+		t.Errorf("CommandLine.Value() = %v, want a b.txt", val)
+	}
+	if args, want := got.Args(), []string{"x", "@y"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("CommandLine.Args() = %v, want %v", args, want)
+	}
+
+	bad := filepath.Join(dir, "bad.rsp")
+	_, err = p.ParseArgs(fs, []string{"@" + bad})
+	var rerr *ResponseFileError
+	if !errors.As(err, &rerr) || rerr.File != bad || rerr.Line != 2 {
+		t.Errorf("Parser.ParseArgs() error = %v, want error at %v line 2", err, bad)
+	}
+	var uerr *UnknownFlagError
+	if !errors.As(err, &uerr) || uerr.Pos != 1 {
+		t.Errorf("Parser.ParseArgs() error = %v, want *UnknownFlagError at 1", err)
+	}
+
+	afs := NewFlagSet()
+	afs.AddNewArg("X", "", true)
+	extra := filepath.Join(dir, "extra.rsp")
+	_, err = p.ParseArgs(afs, []string{"@" + extra})
+	if !errors.As(err, &rerr) || rerr.File != extra || rerr.Line != 2 {
+		t.Errorf("Parser.ParseArgs() error = %v, want error at %v line 2", err, extra)
+	}
+	var aerr *UnexpectedArgError
+	if !errors.As(err, &aerr) || aerr.Token != "b" || aerr.Pos != 1 {
+		t.Errorf("Parser.ParseArgs() error = %v, want *UnexpectedArgError for b at 1", err)
+	}
+
+	_, err = p.ParseArgs(fs, []string{"@" + filepath.Join(dir, "cycle.rsp")})
+	if !errors.As(err, &rerr) || rerr.File != filepath.Join(dir, "cycle2.rsp") || rerr.Line != 1 {
+		t.Errorf("Parser.ParseArgs() error = %v, want cycle error in cycle2.rsp line 1", err)
+	}
+
+	if _, err = p.ParseArgs(fs, []string{"@" + filepath.Join(dir, "missing.rsp")}); !errors.As(err, &rerr) {
+		t.Errorf("Parser.ParseArgs() error = %v, want *ResponseFileError", err)
+	}
+
+	p.ResponseFiles = false
+	got, err = p.ParseArgs(fs, []string{"@args.rsp"})
+	if err != nil || !reflect.DeepEqual(got.Args(), []string{"@args.rsp"}) {
+		t.Errorf("Parser.ParseArgs() = %v, %v, want @args.rsp kept without ResponseFiles", got, err)
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// maxSuggestDistance is the maximum edit distance for a suggestion.
+const maxSuggestDistance = 2
+
+// editDistance returns the Levenshtein distance between the strings a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// suggest returns the candidates similar to name, closest first.
+// Candidates with the same distance are sorted lexicographically.
+func suggest(name string, candidates []string) []string {
+	type match struct {
+		s string
+		d int
+	}
+
+	// Allow fewer edits for short names, otherwise everything matches.
+	limit := maxSuggestDistance
+	if n := utf8.RuneCountInString(name); n <= limit*2 {
+		limit = n / 2
+	}
+
+	var matches []match
+	for _, c := range candidates {
+		if d := editDistance(name, c); d <= limit {
+			matches = append(matches, match{c, d})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].d != matches[j].d {
+			return matches[i].d < matches[j].d
+		}
+		return matches[i].s < matches[j].s
+	})
+
+	var ret []string
+	for _, m := range matches {
+		ret = append(ret, m.s)
+	}
+	return ret
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_editDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"remote", "remote", 0},
+		{"remot", "remote", 1},
+		{"verbsoe", "verbose", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := editDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("editDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_suggest(t *testing.T) {
+	candidates := []string{"remote", "remove", "rebase", "status"}
+
+	tests := []struct {
+		name string
+		arg  string
+		want []string
+	}{
+		{"single", "stats", []string{"status"}},
+		{"ranked", "remot", []string{"remote", "remove"}},
+		{"none", "commit", nil},
+		{"short name", "rm", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suggest(tt.arg, candidates); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suggest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
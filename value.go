@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Value is the interface to a custom Flag value, used to parse and store
+// the Flag argument.
+// Any Value also satisfies the flag.Value interface of the standard library.
+type Value interface {
+	// String returns the current value as a string.
+	String() string
+	// Set parses and stores the value, returning an error if it is invalid.
+	Set(value string) error
+	// Type returns the name of the value type, used by the help Formatter.
+	Type() string
+}
+
+// ValueType represents the type a Flag argument is converted to when parsed.
+type ValueType int
+
+const (
+	TypeString   ValueType = iota // string, no conversion
+	TypeInt                       // int
+	TypeInt64                     // int64
+	TypeUint                      // uint
+	TypeFloat64                   // float64
+	TypeBool                      // bool
+	TypeDuration                  // time.Duration
+	TypeTime                      // time.Time, RFC 3339 or date only
+	TypeURL                       // *url.URL
+	TypeIP                        // net.IP
+	TypeRegexp                    // *regexp.Regexp
+	TypeBytes                     // uint64 byte size, ie. "10MiB"
+)
+
+var typeNames = [...]string{
+	TypeString:   "string",
+	TypeInt:      "int",
+	TypeInt64:    "int64",
+	TypeUint:     "uint",
+	TypeFloat64:  "float64",
+	TypeBool:     "bool",
+	TypeDuration: "duration",
+	TypeTime:     "time",
+	TypeURL:      "url",
+	TypeIP:       "ip",
+	TypeRegexp:   "regexp",
+	TypeBytes:    "bytes",
+}
+
+// String returns the name of the ValueType.
+func (t ValueType) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return "ValueType(" + strconv.Itoa(int(t)) + ")"
+	}
+	return typeNames[t]
+}
+
+// Layouts accepted for TypeTime values, in order of preference.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02",
+}
+
+// convert converts the string value to the specified ValueType.
+func convert(typ ValueType, value string) (interface{}, error) {
+	switch typ {
+	case TypeString:
+		return value, nil
+	case TypeInt:
+		n, err := strconv.ParseInt(value, 0, strconv.IntSize)
+		if err != nil {
+			return nil, numError(err)
+		}
+		return int(n), nil
+	case TypeInt64:
+		n, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			return nil, numError(err)
+		}
+		return n, nil
+	case TypeUint:
+		n, err := strconv.ParseUint(value, 0, strconv.IntSize)
+		if err != nil {
+			return nil, numError(err)
+		}
+		return uint(n), nil
+	case TypeFloat64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, numError(err)
+		}
+		return n, nil
+	case TypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("invalid syntax")
+		}
+		return b, nil
+	case TypeDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case TypeTime:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("expected time in RFC 3339 format")
+	case TypeURL:
+		u, err := url.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		return u, nil
+	case TypeIP:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, errors.New("invalid IP address")
+		}
+		return ip, nil
+	case TypeRegexp:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
+		}
+		return re, nil
+	case TypeBytes:
+		n, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("unknown value type %v", typ)
+}
+
+// numError strips the function and input from strconv errors,
+// the input is already reported by the caller.
+func numError(err error) error {
+	if e, ok := err.(*strconv.NumError); ok {
+		return e.Err
+	}
+	return err
+}
+
+// Byte size unit multipliers, unit names are matched case insensitively.
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1e9,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1e12,
+	"tib": 1 << 40,
+	"p":   1 << 50,
+	"pb":  1e15,
+	"pib": 1 << 50,
+	"e":   1 << 60,
+	"eb":  1e18,
+	"eib": 1 << 60,
+}
+
+// parseBytes parses a byte size such as "512", "1.5GB" or "10MiB".
+// Single letter units (ie. "10M") are treated as binary units.
+func parseBytes(value string) (uint64, error) {
+	s := strings.TrimSpace(value)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !(unicode.IsDigit(r) || r == '.')
+	})
+	if i == -1 {
+		i = len(s)
+	}
+
+	num, unit := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	if len(num) == 0 {
+		return 0, errors.New("invalid byte size")
+	}
+
+	mult, ok := byteUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown byte size unit %q", s[i:])
+	}
+
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, errors.New("invalid byte size")
+	}
+	n *= mult
+	if n >= math.MaxUint64 {
+		return 0, strconv.ErrRange
+	}
+
+	return uint64(n), nil
+}
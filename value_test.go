@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValueType_String(t *testing.T) {
+	tests := []struct {
+		name string
+		t    ValueType
+		want string
+	}{
+		{"string", TypeString, "string"},
+		{"bytes", TypeBytes, "bytes"},
+		{"unknown", ValueType(-1), "ValueType(-1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.String(); got != tt.want {
+				t.Errorf("ValueType.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_convert(t *testing.T) {
+	type args struct {
+		typ   ValueType
+		value string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    interface{}
+		wantErr bool
+	}{
+		{"string", args{TypeString, "abc"}, "abc", false},
+		{"int", args{TypeInt, "-42"}, -42, false},
+		{"int hex", args{TypeInt, "0x10"}, 16, false},
+		{"int invalid", args{TypeInt, "4x2"}, nil, true},
+		{"int64", args{TypeInt64, "9000000000"}, int64(9000000000), false},
+		{"uint negative", args{TypeUint, "-1"}, nil, true},
+		{"float64", args{TypeFloat64, "1.5"}, 1.5, false},
+		{"bool", args{TypeBool, "true"}, true, false},
+		{"bool invalid", args{TypeBool, "yes"}, nil, true},
+		{"duration", args{TypeDuration, "1m30s"}, 90 * time.Second, false},
+		{"time date", args{TypeTime, "2018-02-03"}, time.Date(2018, 2, 3, 0, 0, 0, 0, time.UTC), false},
+		{"time invalid", args{TypeTime, "yesterday"}, nil, true},
+		{"ip", args{TypeIP, "127.0.0.1"}, net.ParseIP("127.0.0.1"), false},
+		{"ip invalid", args{TypeIP, "localhost"}, nil, true},
+		{"regexp invalid", args{TypeRegexp, "a("}, nil, true},
+		{"bytes", args{TypeBytes, "10MiB"}, uint64(10 << 20), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convert(tt.args.typ, tt.args.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("convert() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    uint64
+		wantErr bool
+	}{
+		{"no unit", "512", 512, false},
+		{"bytes", "512B", 512, false},
+		{"decimal", "2kB", 2000, false},
+		{"binary", "2KiB", 2048, false},
+		{"single letter", "1M", 1 << 20, false},
+		{"fraction", "1.5GB", 1500000000, false},
+		{"space", "3 MiB", 3 << 20, false},
+		{"unknown unit", "3 apples", 0, true},
+		{"no number", "MiB", 0, true},
+		{"overflow", "100EiB", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBytes(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseBytes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseBytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// levelValue is a custom Value accepting a fixed set of log levels.
+type levelValue struct {
+	level string
+	set   int
+}
+
+func (v *levelValue) String() string {
+	return v.level
+}
+
+func (v *levelValue) Set(s string) error {
+	switch s {
+	case "debug", "info", "warn", "error":
+		v.level = s
+		v.set++
+		return nil
+	case "true":
+		v.level = "debug"
+		v.set++
+		return nil
+	}
+	return errors.New("unknown level")
+}
+
+func (v *levelValue) Type() string {
+	return "level"
+}
+
+func TestValue_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		hasArg  bool
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"set", true, []string{"--level", "warn"}, "warn", false},
+		{"set attached", true, []string{"--level=error"}, "error", false},
+		{"invalid", true, []string{"--level", "loud"}, "", true},
+		{"no argument", false, []string{"--level"}, "debug", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := new(levelValue)
+			f := NewValueFlag('l', "level", "", v)
+			f.HasArg = tt.hasArg
+
+			fs := NewFlagSet()
+			fs.AddFlag(f)
+
+			_, err := NewParser().ParseArgs(fs, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.ParseArgs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got := v.String(); got != tt.want {
+				t.Errorf("Value.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_argName(t *testing.T) {
+	f := NewValueFlag('l', "level", "", new(levelValue))
+	if got := argName(f); got != "LEVEL" {
+		t.Errorf("argName() = %v, want %v", got, "LEVEL")
+	}
+
+	f.ArgName = "LVL"
+	if got := argName(f); got != "LVL" {
+		t.Errorf("argName() = %v, want %v", got, "LVL")
+	}
+}